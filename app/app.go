@@ -1,11 +1,20 @@
 package app
 
 import (
+	"context"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/joaopmgd/github-tag-api/app/handler"
 	"github.com/joaopmgd/github-tag-api/config"
+	"github.com/joaopmgd/github-tag-api/metrics"
+	"github.com/joaopmgd/github-tag-api/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var log *config.StandardLogger
@@ -23,7 +32,11 @@ func (a *App) Initialize(config *config.Config) {
 	a.Config.Log.InitFunction("Github Tag API")
 	a.Router = mux.NewRouter()
 	a.setRouters()
+	a.Router.Use(tracingMiddleware)
+	a.Router.Use(metricsMiddleware)
 	a.Router.Use(loggingMiddleware)
+	a.Router.Use(a.rateLimitMiddleware)
+	a.Router.Use(a.authMiddleware)
 }
 
 func loggingMiddleware(next http.Handler) http.Handler {
@@ -33,15 +46,145 @@ func loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// statusRecorder keeps the status written by the handler, 200 when it only writes the body
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// metricsMiddleware records the duration of every request by its route template and status
+func metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		metrics.ObserveHTTPRequest(routeTemplate(r), r.Method, recorder.status, time.Since(start))
+	})
+}
+
+// tracingMiddleware serves every request in a span, continuing the trace sent in the W3C trace context headers
+func tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeTemplate(r)
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Tracer().Start(ctx, r.Method+" "+route, trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", r.URL.Path),
+			))
+		defer span.End()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+		span.SetAttributes(attribute.Int("http.response.status_code", recorder.status))
+		if recorder.status >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
+		}
+	})
+}
+
+// routeTemplate is the template of the route matched by the request, as /repos/{user}/starred
+func routeTemplate(r *http.Request) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return "unmatched"
+}
+
+// requestConfig is the config of the request, tracing the database and Github calls under its span
+func (a *App) requestConfig(r *http.Request) *config.Config {
+	return a.Config.WithContext(r.Context())
+}
+
+// rateLimitMiddleware limits the requests of each API key or IP, before the credentials are validated
+func (a *App) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if handler.RateLimit(a.requestConfig(r), w, r) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// authMiddleware finds who is calling from the API key or Github token of the request, rejecting invalid credentials
+func (a *App) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r, ok := handler.Authenticate(a.requestConfig(r), w, r); ok {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// owner Wrap the handler so only the {user} itself or an admin can call it
+func (a *App) owner(f func(w http.ResponseWriter, r *http.Request)) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if handler.AuthorizeOwner(a.requestConfig(r), w, r) {
+			f(w, r)
+		}
+	}
+}
+
+// keyManager Wrap the handler so only the {user} itself or an admin manage its API keys
+func (a *App) keyManager(f func(w http.ResponseWriter, r *http.Request)) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if handler.AuthorizeKeyManager(a.requestConfig(r), w, r) {
+			f(w, r)
+		}
+	}
+}
+
+// reader Wrap the handler so the tags of a private {user} are read only by itself or an admin
+func (a *App) reader(f func(w http.ResponseWriter, r *http.Request)) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if handler.AuthorizeReader(a.requestConfig(r), w, r) {
+			f(w, r)
+		}
+	}
+}
+
 // Set all required routers
 func (a *App) setRouters() {
 	log = a.Config.Log
 	a.Config.Log.SettingUpRouters()
-	a.Get("/repos/{user}/starred", a.GetAllStarredRepos)
-	a.Post("/repos/{user}/starred/{repo}", a.PostTagStarredRepo)
-	a.Delete("/repos/{user}/starred/{repo}", a.DeleteTagStarredRepo)
-	a.Get("/repos/{user}/starred/{repo}/recommendation", a.GetARepoRecommendation)
+	a.Get("/repos/{user}/starred", a.reader(a.GetAllStarredRepos))
+	a.Get("/repos/{user}/starred/search", a.reader(a.SearchStarredRepos))
+	a.Post("/repos/{user}/starred/bulk", a.owner(a.BulkTagStarredRepos))
+	a.Post("/repos/{user}/starred/{repo}", a.owner(a.PostTagStarredRepo))
+	a.Delete("/repos/{user}/starred/{repo}", a.owner(a.DeleteTagStarredRepo))
+	a.Get("/repos/{user}/starred/{repo}/recommendation", a.reader(a.GetARepoRecommendation))
+	a.Post("/repos/{user}/starred/{owner}/{name}", a.owner(a.PostTagStarredRepo))
+	a.Delete("/repos/{user}/starred/{owner}/{name}", a.owner(a.DeleteTagStarredRepo))
+	a.Get("/repos/{user}/starred/{owner}/{name}/recommendation", a.reader(a.GetARepoRecommendation))
+	a.Get("/users/{user}/tags", a.reader(a.GetUserTags))
+	a.Post("/users/{user}/tags/merge", a.owner(a.MergeUserTags))
+	a.Patch("/users/{user}/tags/{tag}", a.owner(a.RenameUserTag))
+	a.Get("/users/{user}/export", a.reader(a.ExportUserTags))
+	a.Post("/users/{user}/import", a.owner(a.ImportUserTags))
+	a.Get("/users/{user}/rules", a.reader(a.GetAutoTagRules))
+	a.Post("/users/{user}/rules", a.owner(a.CreateAutoTagRule))
+	a.Post("/users/{user}/rules/dry-run", a.reader(a.DryRunNewAutoTagRule))
+	a.Get("/users/{user}/rules/{rule}", a.reader(a.GetAutoTagRule))
+	a.Put("/users/{user}/rules/{rule}", a.owner(a.UpdateAutoTagRule))
+	a.Delete("/users/{user}/rules/{rule}", a.owner(a.DeleteAutoTagRule))
+	a.Post("/users/{user}/rules/{rule}/dry-run", a.reader(a.DryRunAutoTagRule))
+	a.Get("/users/{user}/settings", a.owner(a.GetUserSettings))
+	a.Put("/users/{user}/settings", a.owner(a.UpdateUserSettings))
+	a.Get("/users/{user}/keys", a.keyManager(a.GetAPIKeys))
+	a.Post("/users/{user}/keys", a.keyManager(a.CreateAPIKey))
+	a.Delete("/users/{user}/keys/{key}", a.keyManager(a.RevokeAPIKey))
+	a.Delete("/users/{user}/cache", a.owner(a.InvalidateStarredReposCache))
+	a.Post("/users/{user}/sync", a.owner(a.SyncUserStarredRepos))
+	a.Get("/auth/me", a.GetAuthenticatedPrincipal)
+	a.Post("/auth/device", a.StartDeviceAuth)
+	a.Post("/auth/device/token", a.PollDeviceAuth)
 	a.Get("/health", a.HealthStatus)
+	a.Router.Handle("/metrics", metrics.Handler()).Methods("GET")
 }
 
 // Get Wrap the router for GET method
@@ -54,6 +197,16 @@ func (a *App) Post(path string, f func(w http.ResponseWriter, r *http.Request))
 	a.Router.HandleFunc(path, f).Methods("POST")
 }
 
+// Put Wrap the router for PUT method
+func (a *App) Put(path string, f func(w http.ResponseWriter, r *http.Request)) {
+	a.Router.HandleFunc(path, f).Methods("PUT")
+}
+
+// Patch Wrap the router for PATCH method
+func (a *App) Patch(path string, f func(w http.ResponseWriter, r *http.Request)) {
+	a.Router.HandleFunc(path, f).Methods("PATCH")
+}
+
 // Delete Wrap the router for DELETE method
 func (a *App) Delete(path string, f func(w http.ResponseWriter, r *http.Request)) {
 	a.Router.HandleFunc(path, f).Methods("DELETE")
@@ -61,31 +214,158 @@ func (a *App) Delete(path string, f func(w http.ResponseWriter, r *http.Request)
 
 // GetAllStarredRepos Handlers to manage all starred repos
 func (a *App) GetAllStarredRepos(w http.ResponseWriter, r *http.Request) {
-	handler.GetAllStarredRepos(a.Config, w, r)
+	handler.GetAllStarredRepos(a.requestConfig(r), w, r)
+}
+
+// SearchStarredRepos Handlers to search the starred repos of an user
+func (a *App) SearchStarredRepos(w http.ResponseWriter, r *http.Request) {
+	handler.SearchStarredRepos(a.requestConfig(r), w, r)
 }
 
 // PostTagStarredRepo Handlers to post a new tag to a repo
 func (a *App) PostTagStarredRepo(w http.ResponseWriter, r *http.Request) {
-	handler.PostTagStarredRepo(a.Config, w, r)
+	handler.PostTagStarredRepo(a.requestConfig(r), w, r)
+}
+
+// BulkTagStarredRepos Handlers to add and remove tags of many repos
+func (a *App) BulkTagStarredRepos(w http.ResponseWriter, r *http.Request) {
+	handler.BulkTagStarredRepos(a.requestConfig(r), w, r)
 }
 
 // DeleteTagStarredRepo Handlers to post a new tag to a repo
 func (a *App) DeleteTagStarredRepo(w http.ResponseWriter, r *http.Request) {
-	handler.DeleteTagStarredRepo(a.Config, w, r)
+	handler.DeleteTagStarredRepo(a.requestConfig(r), w, r)
 }
 
 // GetARepoRecommendation Handlers to get recommendations based on a language
 func (a *App) GetARepoRecommendation(w http.ResponseWriter, r *http.Request) {
-	handler.GetARepoRecommendation(a.Config, w, r)
+	handler.GetARepoRecommendation(a.requestConfig(r), w, r)
+}
+
+// GetUserTags Handlers to list the tags used by an user
+func (a *App) GetUserTags(w http.ResponseWriter, r *http.Request) {
+	handler.GetUserTags(a.requestConfig(r), w, r)
+}
+
+// RenameUserTag Handlers to rename a tag in every repo of an user
+func (a *App) RenameUserTag(w http.ResponseWriter, r *http.Request) {
+	handler.RenameUserTag(a.requestConfig(r), w, r)
+}
+
+// MergeUserTags Handlers to merge tags of an user into one
+func (a *App) MergeUserTags(w http.ResponseWriter, r *http.Request) {
+	handler.MergeUserTags(a.requestConfig(r), w, r)
+}
+
+// ExportUserTags Handlers to export every starred repo of an user with its tags
+func (a *App) ExportUserTags(w http.ResponseWriter, r *http.Request) {
+	handler.ExportUserTags(a.requestConfig(r), w, r)
+}
+
+// ImportUserTags Handlers to tag the starred repos of an user from a file
+func (a *App) ImportUserTags(w http.ResponseWriter, r *http.Request) {
+	handler.ImportUserTags(a.requestConfig(r), w, r)
+}
+
+// GetAutoTagRules Handlers to list the auto tagging rules of an user
+func (a *App) GetAutoTagRules(w http.ResponseWriter, r *http.Request) {
+	handler.GetAutoTagRules(a.requestConfig(r), w, r)
+}
+
+// CreateAutoTagRule Handlers to create an auto tagging rule
+func (a *App) CreateAutoTagRule(w http.ResponseWriter, r *http.Request) {
+	handler.CreateAutoTagRule(a.requestConfig(r), w, r)
+}
+
+// GetAutoTagRule Handlers to get an auto tagging rule
+func (a *App) GetAutoTagRule(w http.ResponseWriter, r *http.Request) {
+	handler.GetAutoTagRule(a.requestConfig(r), w, r)
+}
+
+// UpdateAutoTagRule Handlers to replace an auto tagging rule
+func (a *App) UpdateAutoTagRule(w http.ResponseWriter, r *http.Request) {
+	handler.UpdateAutoTagRule(a.requestConfig(r), w, r)
+}
+
+// DeleteAutoTagRule Handlers to delete an auto tagging rule
+func (a *App) DeleteAutoTagRule(w http.ResponseWriter, r *http.Request) {
+	handler.DeleteAutoTagRule(a.requestConfig(r), w, r)
+}
+
+// DryRunAutoTagRule Handlers to list the repos matched by a stored rule
+func (a *App) DryRunAutoTagRule(w http.ResponseWriter, r *http.Request) {
+	handler.DryRunAutoTagRule(a.requestConfig(r), w, r)
+}
+
+// DryRunNewAutoTagRule Handlers to list the repos matched by an unsaved rule
+func (a *App) DryRunNewAutoTagRule(w http.ResponseWriter, r *http.Request) {
+	handler.DryRunNewAutoTagRule(a.requestConfig(r), w, r)
+}
+
+// InvalidateStarredReposCache Handlers to remove the cached starred repos of an user
+func (a *App) InvalidateStarredReposCache(w http.ResponseWriter, r *http.Request) {
+	handler.InvalidateStarredReposCache(a.requestConfig(r), w, r)
+}
+
+// SyncUserStarredRepos Handlers to sync the starred repos mirror of an user
+func (a *App) SyncUserStarredRepos(w http.ResponseWriter, r *http.Request) {
+	handler.SyncUserStarredRepos(a.requestConfig(r), w, r)
+}
+
+// GetUserSettings Handlers to show the preferences of an user
+func (a *App) GetUserSettings(w http.ResponseWriter, r *http.Request) {
+	handler.GetUserSettings(a.requestConfig(r), w, r)
+}
+
+// UpdateUserSettings Handlers to replace the preferences of an user
+func (a *App) UpdateUserSettings(w http.ResponseWriter, r *http.Request) {
+	handler.UpdateUserSettings(a.requestConfig(r), w, r)
+}
+
+// GetAPIKeys Handlers to list the API keys of an user
+func (a *App) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	handler.GetAPIKeys(a.requestConfig(r), w, r)
+}
+
+// CreateAPIKey Handlers to issue a new API key for an user
+func (a *App) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	handler.CreateAPIKey(a.requestConfig(r), w, r)
+}
+
+// RevokeAPIKey Handlers to revoke an API key of an user
+func (a *App) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	handler.RevokeAPIKey(a.requestConfig(r), w, r)
+}
+
+// GetAuthenticatedPrincipal Handlers to show who is calling the API
+func (a *App) GetAuthenticatedPrincipal(w http.ResponseWriter, r *http.Request) {
+	handler.GetAuthenticatedPrincipal(a.requestConfig(r), w, r)
+}
+
+// StartDeviceAuth Handlers to start the Github device flow
+func (a *App) StartDeviceAuth(w http.ResponseWriter, r *http.Request) {
+	handler.StartDeviceAuth(a.requestConfig(r), w, r)
+}
+
+// PollDeviceAuth Handlers to exchange a device code for a Github token
+func (a *App) PollDeviceAuth(w http.ResponseWriter, r *http.Request) {
+	handler.PollDeviceAuth(a.requestConfig(r), w, r)
 }
 
 // HealthStatus returns the health status of the app
 func (a *App) HealthStatus(w http.ResponseWriter, r *http.Request) {
-	handler.HealthStatus(a.Config, w, r)
+	handler.HealthStatus(a.requestConfig(r), w, r)
 }
 
 // Run the app on it's router
 func (a *App) Run(host string) {
+	if a.Config.MirrorSyncInterval > 0 {
+		go handler.RunMirrorSync(a.Config, nil)
+	}
 	a.Config.Log.ListeningPort(host)
-	log.Fatal(http.ListenAndServe(host, a.Router))
+	err := http.ListenAndServe(host, a.Router)
+	if a.Config.ShutdownTracing != nil {
+		a.Config.ShutdownTracing(context.Background())
+	}
+	log.Fatal(err)
 }
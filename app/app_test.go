@@ -0,0 +1,101 @@
+package app
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/joaopmgd/github-tag-api/config"
+	"github.com/joaopmgd/github-tag-api/database"
+	"github.com/joaopmgd/github-tag-api/github"
+	"github.com/joaopmgd/github-tag-api/tracing/tracingtest"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// newTestApp initializes the app on the in-memory store, with the environment Initialize requires
+func newTestApp(t *testing.T) *App {
+	t.Setenv("GITHUB_PROPERTIES_ENDPOINT", "https://api.github.com")
+	t.Setenv("GITHUB_USER_STARRED", "/users/{{ .user }}/starred")
+	t.Setenv("GITHUB_HEALTH_STATUS", "https://www.githubstatus.com/api/v2/status.json")
+	t.Setenv("HOST", ":3000")
+	log := config.NewLogger()
+	log.Out = ioutil.Discard
+	a := &App{}
+	a.Initialize(&config.Config{
+		Endpoints: &config.Endpoint{GithubUserStarred: "/users/{{ .user }}/starred"},
+		Log:       log,
+		DB:        database.NewMemory(),
+		Github:    github.NewClient(nil, 0, 0),
+	})
+	return a
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	exporter := tracingtest.Install(t)
+	a := newTestApp(t)
+	tt := map[string]struct {
+		traceparent string
+		traceID     string
+		parentID    string
+	}{
+		"new_trace":      {"", "", "0000000000000000"},
+		"incoming_trace": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"},
+	}
+	for testName, tc := range tt {
+		exporter.Reset()
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		if tc.traceparent != "" {
+			req.Header.Set("traceparent", tc.traceparent)
+		}
+
+		a.Router.ServeHTTP(httptest.NewRecorder(), req)
+
+		spans := exporter.GetSpans()
+		if len(spans) != 1 || spans[0].Name != "GET /metrics" {
+			t.Fatalf("\nTest %s\nGot spans %v\nWant the GET /metrics span", testName, spans)
+		}
+		span := spans[0]
+		if span.Parent.SpanID().String() != tc.parentID || (tc.traceID != "" && span.SpanContext.TraceID().String() != tc.traceID) {
+			t.Errorf("\nTest %s\nGot trace %s and parent %s\nWant trace %s and parent %s",
+				testName, span.SpanContext.TraceID(), span.Parent.SpanID(), tc.traceID, tc.parentID)
+		}
+		want := attribute.Int("http.response.status_code", http.StatusOK)
+		found := false
+		for _, attr := range span.Attributes {
+			found = found || attr == want
+		}
+		if !found {
+			t.Errorf("\nTest %s\nGot attributes %v\nWant status code 200", testName, span.Attributes)
+		}
+	}
+}
+
+func TestStarredRoutes(t *testing.T) {
+	a := newTestApp(t)
+	tt := map[string]struct {
+		method string
+		path   string
+		route  string
+	}{
+		"search":             {"GET", "/repos/joaopmgd/starred/search", "/repos/{user}/starred/search"},
+		"tag_repo_search":    {"POST", "/repos/joaopmgd/starred/search", "/repos/{user}/starred/{repo}"},
+		"bulk":               {"POST", "/repos/joaopmgd/starred/bulk", "/repos/{user}/starred/bulk"},
+		"untag_repo_bulk":    {"DELETE", "/repos/joaopmgd/starred/bulk", "/repos/{user}/starred/{repo}"},
+		"tag_owner_and_bulk": {"POST", "/repos/joaopmgd/starred/other/bulk", "/repos/{user}/starred/{owner}/{name}"},
+	}
+	for testName, tc := range tt {
+		var match mux.RouteMatch
+
+		matched := a.Router.Match(httptest.NewRequest(tc.method, tc.path, nil), &match)
+
+		route := ""
+		if matched && match.Route != nil {
+			route, _ = match.Route.GetPathTemplate()
+		}
+		if route != tc.route {
+			t.Errorf("\nTest %s\nGot route %s\nWant %s", testName, route, tc.route)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/joaopmgd/github-tag-api/auth"
+	"github.com/joaopmgd/github-tag-api/config"
+	"github.com/joaopmgd/github-tag-api/database"
+)
+
+// deviceScope is the Github scope requested by the device flow, reading the user profile is enough to know its login
+const deviceScope = "read:user"
+
+// Authenticate stores the principal of the Authorization header in the request context.
+// Requests without credentials stay anonymous, and invalid credentials respond 401.
+func Authenticate(config *config.Config, w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	credential := auth.Credential(r)
+	if !config.AuthEnabled || credential == "" {
+		return r, true
+	}
+	principal, err := config.Auth.Authenticate(r.Context(), credential)
+	if err == auth.ErrInvalidCredentials {
+		config.Log.AuthenticationError(err.Error())
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		respondError(w, http.StatusUnauthorized, "Invalid credentials")
+		return r, false
+	}
+	if err != nil {
+		config.Log.AuthenticationError(err.Error())
+		respondError(w, http.StatusServiceUnavailable, "Could not validate the credentials")
+		return r, false
+	}
+	return r.WithContext(auth.WithPrincipal(r.Context(), principal)), true
+}
+
+// AuthorizeOwner lets only the {user} itself or an admin go on, otherwise it responds 401 or 403
+func AuthorizeOwner(config *config.Config, w http.ResponseWriter, r *http.Request) bool {
+	if !config.AuthEnabled {
+		return true
+	}
+	user := mux.Vars(r)["user"]
+	return authorizeScope(config, w, r, auth.ScopeWrite, "Only "+user+" or an admin can change these tags")
+}
+
+// AuthorizeKeyManager lets only the {user} itself or an admin manage its API keys, with a credential that has the admin scope
+func AuthorizeKeyManager(config *config.Config, w http.ResponseWriter, r *http.Request) bool {
+	if !config.AuthEnabled {
+		respondError(w, http.StatusNotImplemented, "API keys need the authentication enabled")
+		return false
+	}
+	user := mux.Vars(r)["user"]
+	return authorizeScope(config, w, r, auth.ScopeAdmin, "Only "+user+" or an admin can manage these keys")
+}
+
+// authorizeScope lets go on the principal that owns the {user} and has the scope, otherwise it responds 401 or 403
+func authorizeScope(config *config.Config, w http.ResponseWriter, r *http.Request, scope, denied string) bool {
+	user := mux.Vars(r)["user"]
+	principal, found := auth.FromContext(r.Context())
+	if !found {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		respondError(w, http.StatusUnauthorized, "Authentication required")
+		return false
+	}
+	if !principal.HasScope(scope) {
+		config.Log.AccessDenied(principal.Login, user)
+		respondError(w, http.StatusForbidden, "The credentials need the "+scope+" scope")
+		return false
+	}
+	if !principal.Owns(user) {
+		config.Log.AccessDenied(principal.Login, user)
+		respondError(w, http.StatusForbidden, denied)
+		return false
+	}
+	return true
+}
+
+// AuthorizeReader lets anyone read the tags of public users, the private ones are read only by themselves and the admins
+func AuthorizeReader(config *config.Config, w http.ResponseWriter, r *http.Request) bool {
+	if !config.AuthEnabled {
+		return true
+	}
+	user := mux.Vars(r)["user"]
+	settings, found := config.DB.GetUserSettings(user)
+	if !found || !settings.Private {
+		return true
+	}
+	principal, found := auth.FromContext(r.Context())
+	if !found {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		respondError(w, http.StatusUnauthorized, "The tags of "+user+" are private")
+		return false
+	}
+	if !principal.CanRead(user) {
+		config.Log.AccessDenied(principal.Login, user)
+		respondError(w, http.StatusForbidden, "The tags of "+user+" are private")
+		return false
+	}
+	return true
+}
+
+// GetAuthenticatedPrincipal shows who is calling the API
+func GetAuthenticatedPrincipal(config *config.Config, w http.ResponseWriter, r *http.Request) {
+	principal, found := auth.FromContext(r.Context())
+	if !found {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		respondError(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	respondJSON(w, http.StatusOK, principal)
+}
+
+// StartDeviceAuth starts the Github OAuth device flow, the user must type the user_code in the verification_uri
+func StartDeviceAuth(config *config.Config, w http.ResponseWriter, r *http.Request) {
+	if config.Endpoints.GithubClientID == "" {
+		respondError(w, http.StatusNotImplemented, "The device flow needs a Github OAuth app client ID")
+		return
+	}
+	code, err := config.Github.RequestDeviceCode(r.Context(), config.Endpoints.GithubOAuthURL, config.Endpoints.GithubClientID, deviceScope)
+	if err != nil {
+		config.Log.UnableToRequest(err.Error())
+		respondError(w, http.StatusBadGateway, "Could not start the device flow")
+		return
+	}
+	respondJSON(w, http.StatusOK, code)
+}
+
+// PollDeviceAuth exchanges an authorized device code for a Github token, which is then sent as "Authorization: Bearer {token}"
+func PollDeviceAuth(config *config.Config, w http.ResponseWriter, r *http.Request) {
+	if config.Endpoints.GithubClientID == "" {
+		respondError(w, http.StatusNotImplemented, "The device flow needs a Github OAuth app client ID")
+		return
+	}
+
+	// Validate body
+	var tokenData model.DeviceTokenRequest
+	err := json.NewDecoder(r.Body).Decode(&tokenData)
+	if err != nil || strings.TrimSpace(tokenData.DeviceCode) == "" {
+		config.Log.CouldNotParseRequestBody(errorMessage(err))
+		respondError(w, http.StatusBadRequest, "Body must have a JSON key named 'device_code' and its value")
+		return
+	}
+
+	token, err := config.Github.PollDeviceToken(r.Context(), config.Endpoints.GithubOAuthURL, config.Endpoints.GithubClientID, tokenData.DeviceCode)
+	if err != nil {
+		config.Log.UnableToRequest(err.Error())
+		respondError(w, http.StatusBadGateway, "Could not poll the device flow")
+		return
+	}
+	switch token.Error {
+	case "":
+	case "authorization_pending", "slow_down":
+		respondJSON(w, http.StatusAccepted, model.DeviceAuthPending{Status: token.Error, Interval: token.Interval})
+		return
+	default:
+		respondError(w, http.StatusBadRequest, token.Error+": "+token.ErrorDescription)
+		return
+	}
+	principal, err := config.Auth.Authenticate(r.Context(), token.AccessToken)
+	if err != nil {
+		config.Log.AuthenticationError(err.Error())
+		respondError(w, http.StatusBadGateway, "Could not find the user of the token")
+		return
+	}
+	respondJSON(w, http.StatusOK, model.DeviceTokenResponse{
+		AccessToken: token.AccessToken,
+		TokenType:   "bearer",
+		Scope:       token.Scope,
+		Login:       principal.Login,
+	})
+}
+
+// GetUserSettings shows the preferences of an user
+func GetUserSettings(config *config.Config, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	settings, _ := config.DB.GetUserSettings(vars["user"])
+	respondJSON(w, http.StatusOK, model.UserSettings{Private: settings.Private})
+}
+
+// UpdateUserSettings replaces the preferences of an user
+func UpdateUserSettings(config *config.Config, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	// Validate body
+	var settingsData model.UserSettings
+	if err := json.NewDecoder(r.Body).Decode(&settingsData); err != nil {
+		config.Log.CouldNotParseRequestBody(err.Error())
+		respondError(w, http.StatusBadRequest, "Body must have a JSON key named 'private' and its value")
+		return
+	}
+	if err := config.DB.SaveUserSettings(database.UserSettings{UserID: vars["user"], Private: settingsData.Private}); err != nil {
+		config.Log.DatabaseError(err.Error())
+		respondError(w, http.StatusInternalServerError, "Could not save the settings")
+		return
+	}
+	respondJSON(w, http.StatusOK, settingsData)
+}
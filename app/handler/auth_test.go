@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/joaopmgd/github-tag-api/auth"
+	"github.com/joaopmgd/github-tag-api/config"
+)
+
+// fakeAuthenticator knows the principal of each credential, any other credential is invalid
+type fakeAuthenticator map[string]auth.Principal
+
+func (f fakeAuthenticator) Authenticate(ctx context.Context, credential string) (auth.Principal, error) {
+	principal, found := f[credential]
+	if !found {
+		return auth.Principal{}, auth.ErrInvalidCredentials
+	}
+	return principal, nil
+}
+
+func newAuthTestConfig(t *testing.T) *config.Config {
+	c := newTestConfig(t)
+	c.AuthEnabled = true
+	c.Auth = fakeAuthenticator{
+		"owner-token": {Login: "joaopmgd", Method: auth.MethodGithubToken},
+		"other-token": {Login: "someone", Method: auth.MethodGithubToken},
+		"admin-token": {Login: "root", Admin: true, Method: auth.MethodGithubToken},
+		"gho_device":  {Login: "joaopmgd", Method: auth.MethodGithubToken},
+	}
+	return c
+}
+
+// executeAuthorizedTest authenticates the request like the middleware and runs the handler only if the check lets it go on
+func executeAuthorizedTest(c *config.Config, check func(*config.Config, http.ResponseWriter, *http.Request) bool, handler func(*config.Config, http.ResponseWriter, *http.Request), method, credential, body string, vars map[string]string) *httptest.ResponseRecorder {
+	return executeHandlerTest(c, func(c *config.Config, w http.ResponseWriter, r *http.Request) {
+		if credential != "" {
+			r.Header.Set("Authorization", "Bearer "+credential)
+		}
+		r, ok := Authenticate(c, w, r)
+		if ok && check(c, w, r) {
+			handler(c, w, r)
+		}
+	}, method, "/", body, vars)
+}
+
+func TestAuthorizeOwner(t *testing.T) {
+	c := newAuthTestConfig(t)
+	repo := map[string]string{"user": "joaopmgd", "repo": "10866521"}
+	tt := map[string]struct {
+		credential     string
+		responseStatus int
+		responseBody   string
+	}{
+		"anonymous":      {"", http.StatusUnauthorized, `{"error":"Authentication required"}`},
+		"invalid_token":  {"unknown", http.StatusUnauthorized, `{"error":"Invalid credentials"}`},
+		"other_user":     {"other-token", http.StatusForbidden, `{"error":"Only joaopmgd or an admin can change these tags"}`},
+		"owner":          {"owner-token", http.StatusOK, `{"Message":"Tag added"}`},
+		"admin_repeated": {"admin-token", http.StatusBadRequest, `{"error":"Repository already has the tag : router"}`},
+	}
+	for _, name := range []string{"anonymous", "invalid_token", "other_user", "owner", "admin_repeated"} {
+		tc := tt[name]
+
+		rr := executeAuthorizedTest(c, AuthorizeOwner, PostTagStarredRepo, "POST", tc.credential, `{"tag": "router"}`, repo)
+
+		if rr.Code != tc.responseStatus || rr.Body.String() != tc.responseBody {
+			t.Errorf("\nTest %s\nGot Status %v and Body %s\nWant Status %v and Body %s",
+				name, rr.Code, rr.Body.String(), tc.responseStatus, tc.responseBody)
+		}
+	}
+}
+
+func TestAuthorizeOwnerDisabled(t *testing.T) {
+	c := newTestConfig(t)
+
+	rr := executeAuthorizedTest(c, AuthorizeOwner, PostTagStarredRepo, "POST", "anything", `{"tag": "router"}`, map[string]string{"user": "joaopmgd", "repo": "10866521"})
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Got Status %v and Body %s\nWant Status %v", rr.Code, rr.Body.String(), http.StatusOK)
+	}
+}
+
+func TestAuthorizeReader(t *testing.T) {
+	c := newAuthTestConfig(t)
+	user := map[string]string{"user": "joaopmgd"}
+	steps := []struct {
+		name           string
+		check          func(*config.Config, http.ResponseWriter, *http.Request) bool
+		handler        func(*config.Config, http.ResponseWriter, *http.Request)
+		method         string
+		credential     string
+		body           string
+		responseStatus int
+		responseBody   string
+	}{
+		{"public_anonymous", AuthorizeReader, GetUserTags, "GET", "", "", http.StatusOK, `{"tags":[],"page_number":0,"page_size":10,"properties_total_count":0}`},
+		{"settings_anonymous", AuthorizeOwner, GetUserSettings, "GET", "", "", http.StatusUnauthorized, `{"error":"Authentication required"}`},
+		{"settings_default", AuthorizeOwner, GetUserSettings, "GET", "owner-token", "", http.StatusOK, `{"private":false}`},
+		{"settings_other_user", AuthorizeOwner, UpdateUserSettings, "PUT", "other-token", `{"private": true}`, http.StatusForbidden, `{"error":"Only joaopmgd or an admin can change these tags"}`},
+		{"settings_invalid_body", AuthorizeOwner, UpdateUserSettings, "PUT", "owner-token", `private`, http.StatusBadRequest, `{"error":"Body must have a JSON key named 'private' and its value"}`},
+		{"set_private", AuthorizeOwner, UpdateUserSettings, "PUT", "owner-token", `{"private": true}`, http.StatusOK, `{"private":true}`},
+		{"private_anonymous", AuthorizeReader, GetUserTags, "GET", "", "", http.StatusUnauthorized, `{"error":"The tags of joaopmgd are private"}`},
+		{"private_other_user", AuthorizeReader, GetUserTags, "GET", "other-token", "", http.StatusForbidden, `{"error":"The tags of joaopmgd are private"}`},
+		{"private_owner", AuthorizeReader, GetUserTags, "GET", "owner-token", "", http.StatusOK, `{"tags":[],"page_number":0,"page_size":10,"properties_total_count":0}`},
+		{"private_admin", AuthorizeReader, GetUserTags, "GET", "admin-token", "", http.StatusOK, `{"tags":[],"page_number":0,"page_size":10,"properties_total_count":0}`},
+		{"admin_set_public", AuthorizeOwner, UpdateUserSettings, "PUT", "admin-token", `{"private": false}`, http.StatusOK, `{"private":false}`},
+		{"public_again", AuthorizeReader, GetUserTags, "GET", "other-token", "", http.StatusOK, `{"tags":[],"page_number":0,"page_size":10,"properties_total_count":0}`},
+	}
+	for _, step := range steps {
+
+		rr := executeAuthorizedTest(c, step.check, step.handler, step.method, step.credential, step.body, user)
+
+		if rr.Code != step.responseStatus || rr.Body.String() != step.responseBody {
+			t.Fatalf("\nTest %s\nGot Status %v and Body %s\nWant Status %v and Body %s",
+				step.name, rr.Code, rr.Body.String(), step.responseStatus, step.responseBody)
+		}
+	}
+}
+
+func TestGetAuthenticatedPrincipal(t *testing.T) {
+	c := newAuthTestConfig(t)
+	tt := map[string]struct {
+		credential     string
+		responseStatus int
+		responseBody   string
+	}{
+		"anonymous": {"", http.StatusUnauthorized, `{"error":"Authentication required"}`},
+		"owner":     {"owner-token", http.StatusOK, `{"login":"joaopmgd","admin":false,"method":"github_token"}`},
+		"admin":     {"admin-token", http.StatusOK, `{"login":"root","admin":true,"method":"github_token"}`},
+	}
+	allow := func(*config.Config, http.ResponseWriter, *http.Request) bool { return true }
+	for testName, tc := range tt {
+
+		rr := executeAuthorizedTest(c, allow, GetAuthenticatedPrincipal, "GET", tc.credential, "", nil)
+
+		if rr.Code != tc.responseStatus || rr.Body.String() != tc.responseBody {
+			t.Errorf("\nTest %s\nGot Status %v and Body %s\nWant Status %v and Body %s",
+				testName, rr.Code, rr.Body.String(), tc.responseStatus, tc.responseBody)
+		}
+	}
+}
+
+func TestDeviceAuth(t *testing.T) {
+	c := newAuthTestConfig(t)
+	router := mux.NewRouter()
+	router.HandleFunc("/login/device/code", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(model.GithubDeviceCode{DeviceCode: "device", UserCode: "ABCD-1234", VerificationURI: "https://github.com/login/device", ExpiresIn: 900, Interval: 5})
+	}).Methods("POST")
+	router.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch r.PostForm.Get("device_code") {
+		case "pending":
+			json.NewEncoder(w).Encode(model.GithubDeviceToken{Error: "authorization_pending", Interval: 5})
+		case "expired":
+			json.NewEncoder(w).Encode(model.GithubDeviceToken{Error: "expired_token", ErrorDescription: "The device code has expired"})
+		default:
+			json.NewEncoder(w).Encode(model.GithubDeviceToken{AccessToken: "gho_device", TokenType: "bearer", Scope: "read:user"})
+		}
+	}).Methods("POST")
+	server := httptest.NewServer(router)
+	defer server.Close()
+	c.Endpoints.GithubOAuthURL = server.URL
+	c.Endpoints.GithubClientID = "client"
+
+	steps := []struct {
+		name           string
+		handler        func(*config.Config, http.ResponseWriter, *http.Request)
+		body           string
+		responseStatus int
+		responseBody   string
+	}{
+		{"start", StartDeviceAuth, "", http.StatusOK, `{"device_code":"device","user_code":"ABCD-1234","verification_uri":"https://github.com/login/device","expires_in":900,"interval":5}`},
+		{"missing_code", PollDeviceAuth, `{}`, http.StatusBadRequest, `{"error":"Body must have a JSON key named 'device_code' and its value"}`},
+		{"pending", PollDeviceAuth, `{"device_code": "pending"}`, http.StatusAccepted, `{"status":"authorization_pending","interval":5}`},
+		{"expired", PollDeviceAuth, `{"device_code": "expired"}`, http.StatusBadRequest, `{"error":"expired_token: The device code has expired"}`},
+		{"authorized", PollDeviceAuth, `{"device_code": "device"}`, http.StatusOK, `{"access_token":"gho_device","token_type":"bearer","scope":"read:user","login":"joaopmgd"}`},
+	}
+	for _, step := range steps {
+
+		rr := executeHandlerTest(c, step.handler, "POST", "/", step.body, nil)
+
+		if rr.Code != step.responseStatus || rr.Body.String() != step.responseBody {
+			t.Errorf("\nTest %s\nGot Status %v and Body %s\nWant Status %v and Body %s",
+				step.name, rr.Code, rr.Body.String(), step.responseStatus, step.responseBody)
+		}
+	}
+
+	c.Endpoints.GithubClientID = ""
+	rr := executeHandlerTest(c, StartDeviceAuth, "POST", "/", "", nil)
+	if rr.Code != http.StatusNotImplemented {
+		t.Errorf("Got Status %v and Body %s\nWant Status %v", rr.Code, rr.Body.String(), http.StatusNotImplemented)
+	}
+}
+
+func TestUserSettingsSaved(t *testing.T) {
+	c := newTestConfig(t)
+
+	executeHandlerTest(c, UpdateUserSettings, "PUT", "/", `{"private": true}`, map[string]string{"user": "joaopmgd"})
+
+	if settings, found := c.DB.GetUserSettings("joaopmgd"); !found || settings.UserID != "joaopmgd" || !settings.Private {
+		t.Errorf("Got %+v and found %v\nWant private settings for joaopmgd", settings, found)
+	}
+}
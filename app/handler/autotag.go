@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"encoding/json"
+
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/joaopmgd/github-tag-api/app/rules"
+	"github.com/joaopmgd/github-tag-api/config"
+	"github.com/joaopmgd/github-tag-api/database"
+	"github.com/joaopmgd/github-tag-api/metrics"
+)
+
+// applyAutoTags creates the automatic tags of the starred repos, when they change on Github and when the rules of the user change
+func applyAutoTags(config *config.Config, user string, userStarredRepos []model.StarredRepoRequest) {
+	var values []database.RepoTag
+	if config.AutoTagTopics {
+		values = append(values, topicTags(userStarredRepos)...)
+	}
+	values = append(values, ruleTags(config, user, userStarredRepos)...)
+	if len(values) == 0 {
+		return
+	}
+	languages := make(map[int64]string)
+	for _, repo := range userStarredRepos {
+		languages[repo.ID] = repo.Language
+	}
+	inserted, err := config.DB.InsertAutoRepoTags(user, values, languages)
+	if err != nil {
+		config.Log.DatabaseError(err.Error())
+		return
+	}
+	if inserted > 0 {
+		config.Log.AutoTagsCreated(user, inserted)
+		metrics.AddTags(tagSourceAuto, inserted)
+	}
+}
+
+// applyUserAutoTags creates the automatic tags of the user starred repos right away, as after a rule changes
+func applyUserAutoTags(config *config.Config, vars map[string]string) {
+	URL, err := config.GetStarredReposURL(vars)
+	if err != nil {
+		return
+	}
+	userStarredRepos, err := getUserStarredReposOr404(config, vars["user"], URL)
+	if err != nil {
+		config.Log.UnableToRequest(err.Error())
+		return
+	}
+	applyAutoTags(config, vars["user"], userStarredRepos)
+}
+
+// topicTags creates a tag for each Github topic of the repos
+func topicTags(userStarredRepos []model.StarredRepoRequest) []database.RepoTag {
+	var values []database.RepoTag
+	for _, repo := range userStarredRepos {
+		for _, topic := range repo.Topics {
+			values = append(values, database.RepoTag{RepoID: repo.ID, TagName: topic, Source: database.TagSourceGithubTopic})
+		}
+	}
+	return values
+}
+
+// ruleTags creates a tag for each repo matched by the rules of the user, a rule that can not be evaluated is skipped
+func ruleTags(config *config.Config, user string, userStarredRepos []model.StarredRepoRequest) []database.RepoTag {
+	var values []database.RepoTag
+	for _, stored := range config.DB.GetAutoTagRules(user) {
+		rule, err := toAutoTagRule(stored)
+		if err == nil {
+			err = rules.Validate(rule)
+		}
+		if err != nil {
+			config.Log.InvalidAutoTagRule(user, stored.ID, err.Error())
+			continue
+		}
+		for _, repo := range userStarredRepos {
+			if rules.Matches(rule, repo) {
+				values = append(values, database.RepoTag{RepoID: repo.ID, TagName: rule.Tag, Source: database.TagSourceRule, RuleID: rule.ID})
+			}
+		}
+	}
+	return values
+}
+
+// toAutoTagRule decodes the conditions of a stored rule
+func toAutoTagRule(stored database.AutoTagRule) (model.AutoTagRule, error) {
+	rule := model.AutoTagRule{ID: stored.ID, Name: stored.Name, Tag: stored.TagName, Conditions: []model.RuleCondition{}}
+	if stored.Conditions == "" {
+		return rule, nil
+	}
+	return rule, json.Unmarshal([]byte(stored.Conditions), &rule.Conditions)
+}
+
+// tagOrigins has the source of the tags that were not created by the user, and the rule of the ones created by a rule
+type tagOrigins struct {
+	sources map[int64]map[string]string
+	rules   map[int64]map[string]uint
+}
+
+// repoTagsMaps groups the tags by repo, with the origin of the ones that were not created by the user
+func repoTagsMaps(repoTags []database.RepoTag) (map[int64][]string, tagOrigins) {
+	tags := make(map[int64][]string)
+	origins := tagOrigins{sources: make(map[int64]map[string]string), rules: make(map[int64]map[string]uint)}
+	for _, repoTag := range repoTags {
+		tags[repoTag.RepoID] = append(tags[repoTag.RepoID], repoTag.TagName)
+		if repoTag.Source != database.TagSourceUser {
+			if origins.sources[repoTag.RepoID] == nil {
+				origins.sources[repoTag.RepoID] = make(map[string]string)
+			}
+			origins.sources[repoTag.RepoID][repoTag.TagName] = repoTag.Source
+		}
+		if repoTag.RuleID != 0 {
+			if origins.rules[repoTag.RepoID] == nil {
+				origins.rules[repoTag.RepoID] = make(map[string]uint)
+			}
+			origins.rules[repoTag.RepoID][repoTag.TagName] = repoTag.RuleID
+		}
+	}
+	return tags, origins
+}
+
+// addTagSources tells the source of the tags that were not created by the user, and which rule created each rule tag
+func addTagSources(starredRepos []model.StarredRepoTags, origins tagOrigins) []model.StarredRepoTags {
+	for i := range starredRepos {
+		starredRepos[i].TagSources = origins.sources[starredRepos[i].ID]
+		starredRepos[i].TagRules = origins.rules[starredRepos[i].ID]
+	}
+	return starredRepos
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/joaopmgd/github-tag-api/database"
+)
+
+func TestAutoTagTopics(t *testing.T) {
+	c := newTestConfig(t)
+	c.AutoTagTopics = true
+	user := map[string]string{"user": "joaopmgd"}
+	c.DB.InsertRepoTagsValue(database.RepoTag{UserID: "joaopmgd", RepoID: 10866521, TagName: "router"})
+
+	response := executeHandlerTest(c, GetAllStarredRepos, "GET", "/", "", user)
+
+	var starred model.StarredRepoTagsResponse
+	json.NewDecoder(response.Body).Decode(&starred)
+	mux := starred.StarredRepos[0]
+	if response.Code != http.StatusOK ||
+		!reflect.DeepEqual(mux.Tags, []string{"router", "go"}) ||
+		!reflect.DeepEqual(mux.TagSources, map[string]string{"go": database.TagSourceGithubTopic}) {
+		t.Errorf("Got status %v, tags %v and sources %v, want router from the user and go from the topics",
+			response.Code, mux.Tags, mux.TagSources)
+	}
+	if counts := c.DB.GetRecommendationTagByLanguage("Go", 10, 1); !reflect.DeepEqual(counts, []database.TagCount{{TagName: "go", Repos: 1, Users: 1}}) {
+		t.Errorf("Got Go recommendations %v, want the topic tag", counts)
+	}
+
+	// A topic tag removed by the user is not created again
+	c.DB.DeleteRepoTagsValue(database.RepoTag{UserID: "joaopmgd", RepoID: 10866521, TagName: "go"})
+	c.Github.InvalidateStarredRepos("joaopmgd")
+	executeHandlerTest(c, GetAllStarredRepos, "GET", "/", "", user)
+
+	if tags := c.DB.GetAllRepoTagsMap("joaopmgd"); !reflect.DeepEqual(tags, map[int64][]string{10866521: {"router"}}) {
+		t.Errorf("Got tags %v after removing the topic tag", tags)
+	}
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/joaopmgd/github-tag-api/config"
+	"github.com/joaopmgd/github-tag-api/database"
+)
+
+// Status of each bulk operation
+const (
+	bulkStatusApplied    = "applied"
+	bulkStatusUnchanged  = "unchanged"
+	bulkStatusInvalid    = "invalid"
+	bulkStatusNotApplied = "not_applied"
+)
+
+// BulkTagStarredRepos adds and removes tags of many repos, either every operation is applied or none
+func BulkTagStarredRepos(config *config.Config, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	// Validate body
+	var bulkData model.BulkTagRequest
+	err := json.NewDecoder(r.Body).Decode(&bulkData)
+	if err != nil || len(bulkData.Operations) == 0 {
+		config.Log.CouldNotParseRequestBody(errorMessage(err))
+		respondError(w, http.StatusBadRequest, "Body must have a JSON key named 'operations' with the repo_id and the tags to add or remove")
+		return
+	}
+
+	// Validate URL
+	URL, err := config.GetStarredReposURL(vars)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Validate request to github, a single time for every operation
+	userStarredRepos, err := getUserStarredReposOr404(config, vars["user"], URL)
+	if err != nil {
+		config.Log.UnableToRequest(err.Error())
+		respondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	results, changes, valid := planBulkTagOperations(bulkData.Operations, userStarredRepos, config.DB.GetAllRepoTagsMap(vars["user"]))
+	if !valid {
+		respondJSON(w, http.StatusBadRequest, model.BulkTagResponse{Applied: false, Results: results})
+		return
+	}
+	if err := config.DB.BulkUpdateRepoTags(vars["user"], changes); err != nil {
+		config.Log.DatabaseError(err.Error())
+		respondError(w, http.StatusInternalServerError, "Could not change the tags")
+		return
+	}
+	countTagChanges(tagSourceBulk, changes)
+	respondJSON(w, http.StatusOK, model.BulkTagResponse{Applied: true, Results: results})
+}
+
+// planBulkTagOperations validates every operation against the starred repos and their current tags,
+// returning the result of each one and the changes to apply. When any operation is invalid nothing should be applied.
+func planBulkTagOperations(operations []model.BulkTagOperation, userStarredRepos []model.StarredRepoRequest, tags map[int64][]string) ([]model.BulkTagResult, []database.RepoTagChange, bool) {
+	reposByID := make(map[int64]model.StarredRepoRequest)
+	for _, repo := range userStarredRepos {
+		reposByID[repo.ID] = repo
+	}
+	// Tags of each repo as they will be after the previous operations
+	repoTags := make(map[int64]map[string]bool)
+	currentTags := func(repoID int64) map[string]bool {
+		if _, found := repoTags[repoID]; !found {
+			repoTags[repoID] = make(map[string]bool)
+			for _, tag := range tags[repoID] {
+				repoTags[repoID][tag] = true
+			}
+		}
+		return repoTags[repoID]
+	}
+
+	valid := true
+	results := make([]model.BulkTagResult, len(operations))
+	var changes []database.RepoTagChange
+	for i, operation := range operations {
+		result := model.BulkTagResult{RepoID: operation.RepoID, Added: []string{}, Removed: []string{}}
+		repo, found := reposByID[operation.RepoID]
+		if reason := validateBulkTagOperation(operation, found); reason != "" {
+			valid = false
+			result.Status = bulkStatusInvalid
+			result.Error = reason
+			results[i] = result
+			continue
+		}
+		current := currentTags(repo.ID)
+		for _, tag := range operation.Remove {
+			if current[tag] {
+				delete(current, tag)
+				result.Removed = append(result.Removed, tag)
+			}
+		}
+		for _, tag := range operation.Add {
+			if !current[tag] {
+				current[tag] = true
+				result.Added = append(result.Added, tag)
+			}
+		}
+		result.Status = bulkStatusApplied
+		if len(result.Added) == 0 && len(result.Removed) == 0 {
+			result.Status = bulkStatusUnchanged
+		}
+		results[i] = result
+		changes = append(changes, database.RepoTagChange{RepoID: repo.ID, Language: repo.Language, Add: result.Added, Remove: result.Removed})
+	}
+
+	if !valid {
+		for i := range results {
+			if results[i].Status != bulkStatusInvalid {
+				results[i].Status = bulkStatusNotApplied
+			}
+		}
+		return results, nil, false
+	}
+	return results, changes, true
+}
+
+// validateBulkTagOperation returns why the operation is invalid, or an empty string
+func validateBulkTagOperation(operation model.BulkTagOperation, starred bool) string {
+	if !starred {
+		return "Repository not found " + strconv.FormatInt(operation.RepoID, 10)
+	}
+	if len(operation.Add) == 0 && len(operation.Remove) == 0 {
+		return "Operation must add or remove at least one tag"
+	}
+	removed := make(map[string]bool)
+	for _, tag := range operation.Remove {
+		if strings.TrimSpace(tag) == "" {
+			return "Tags must not be empty"
+		}
+		removed[tag] = true
+	}
+	for _, tag := range operation.Add {
+		if strings.TrimSpace(tag) == "" {
+			return "Tags must not be empty"
+		}
+		if removed[tag] {
+			return "Tag is both added and removed : " + tag
+		}
+	}
+	return ""
+}
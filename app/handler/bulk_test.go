@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/joaopmgd/github-tag-api/database"
+)
+
+func TestBulkTagStarredRepos(t *testing.T) {
+	c := newTestConfig(t)
+	c.DB.InsertRepoTagsValue(database.RepoTag{UserID: "joaopmgd", RepoID: 10866521, TagName: "old"})
+	user := map[string]string{"user": "joaopmgd"}
+	steps := []struct {
+		name           string
+		body           string
+		responseStatus int
+		responseBody   string
+		expectedTags   string
+	}{
+		{"no_operations", `{"operations": []}`, http.StatusBadRequest,
+			`{"error":"Body must have a JSON key named 'operations' with the repo_id and the tags to add or remove"}`,
+			"map[10866521:[old]]"},
+		{"invalid_operation", `{"operations": [{"repo_id": 10866521, "add": ["router"]}, {"repo_id": 1, "add": ["x"]}, {"repo_id": 724712, "add": ["a"], "remove": ["a"]}]}`, http.StatusBadRequest,
+			`{"applied":false,"results":[{"repo_id":10866521,"status":"not_applied","added":["router"],"removed":[]},{"repo_id":1,"status":"invalid","added":[],"removed":[],"error":"Repository not found 1"},{"repo_id":724712,"status":"invalid","added":[],"removed":[],"error":"Tag is both added and removed : a"}]}`,
+			"map[10866521:[old]]"},
+		{"applied", `{"operations": [{"repo_id": 10866521, "add": ["router", "go"], "remove": ["old", "missing"]}, {"repo_id": 724712, "add": ["rust"]}, {"repo_id": 724712, "add": ["rust"]}]}`, http.StatusOK,
+			`{"applied":true,"results":[{"repo_id":10866521,"status":"applied","added":["router","go"],"removed":["old"]},{"repo_id":724712,"status":"applied","added":["rust"],"removed":[]},{"repo_id":724712,"status":"unchanged","added":[],"removed":[]}]}`,
+			"map[724712:[rust] 10866521:[router go]]"},
+	}
+	for _, step := range steps {
+
+		response := executeHandlerTest(c, BulkTagStarredRepos, "POST", "/", step.body, user)
+
+		if response.Code != step.responseStatus || response.Body.String() != step.responseBody {
+			t.Errorf("\nStep %s\nGot Status %v and Body %s\nWant Status %v and Body %s",
+				step.name, response.Code, response.Body.String(), step.responseStatus, step.responseBody)
+		}
+		if tags := fmt.Sprint(c.DB.GetAllRepoTagsMap("joaopmgd")); tags != step.expectedTags {
+			t.Errorf("\nStep %s\nGot tags %s\nWant tags %s", step.name, tags, step.expectedTags)
+		}
+	}
+}
+
+func TestBulkRemovedTagLeavesRecommendation(t *testing.T) {
+	c := newTestConfig(t)
+	user := map[string]string{"user": "joaopmgd"}
+	for _, body := range []string{
+		`{"operations": [{"repo_id": 10866521, "add": ["router"]}]}`,
+		`{"operations": [{"repo_id": 10866521, "remove": ["router"]}]}`,
+		`{"operations": [{"repo_id": 10866521, "add": ["router"]}]}`,
+		`{"operations": [{"repo_id": 10866521, "remove": ["router"]}]}`,
+	} {
+		if response := executeHandlerTest(c, BulkTagStarredRepos, "POST", "/", body, user); response.Code != http.StatusOK {
+			t.Fatalf("Got Status %v and Body %s for %s", response.Code, response.Body.String(), body)
+		}
+	}
+
+	response := executeHandlerTest(c, GetARepoRecommendation, "GET", "/", "", map[string]string{"user": "joaopmgd", "repo": "10866521"})
+
+	if want := `{"recommended":["Go"],"counts":[],"suggestions":[]}`; response.Body.String() != want {
+		t.Errorf("Got Body %s\nWant Body %s", response.Body.String(), want)
+	}
+	if counts := c.DB.GetRecommendationTagByLanguage("Go", 10, 1); len(counts) != 0 {
+		t.Errorf("Got language tags %v, want none", counts)
+	}
+}
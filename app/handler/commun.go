@@ -2,7 +2,9 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 )
 
 // respondJSON makes the response with payload as json format
@@ -22,3 +24,24 @@ func respondJSON(w http.ResponseWriter, status int, payload interface{}) {
 func respondError(w http.ResponseWriter, code int, message string) {
 	respondJSON(w, code, map[string]string{"error": message})
 }
+
+// errorMessage is the error text, or empty when there is no error
+func errorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// intParam reads an integer query param between min and max, returning the fallback when it is not set
+func intParam(r *http.Request, name string, fallback, min, max int) (int, error) {
+	value := r.FormValue(name)
+	if value == "" {
+		return fallback, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < min || number > max {
+		return 0, errors.New("Param " + name + " must be a number between " + strconv.Itoa(min) + " and " + strconv.Itoa(max))
+	}
+	return number, nil
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/joaopmgd/github-tag-api/config"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ExportTagsSeparator joins the tags and topics of a repo in a single CSV column
+const ExportTagsSeparator = ";"
+
+// exportColumns is the CSV header, in the order of csvRecord
+var exportColumns = []string{"id", "full_name", "name", "description", "url", "language", "tags", "topics", "starred_at"}
+
+// exportContentTypes has the content type of each export format
+var exportContentTypes = map[string]string{
+	"json": "application/json",
+	"csv":  "text/csv",
+	"yaml": "application/x-yaml",
+}
+
+// ExportUserTags writes every starred repo of an user with its tags, as JSON, CSV or YAML
+func ExportUserTags(config *config.Config, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	// Validate format
+	format := r.FormValue("format")
+	if format == "" {
+		format = "json"
+	}
+	contentType, found := exportContentTypes[format]
+	if !found {
+		respondError(w, http.StatusBadRequest, "Format must be one of: json, csv, yaml")
+		return
+	}
+
+	// Validate URL
+	URL, err := config.GetStarredReposURL(vars)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userStarredRepos, err := getUserStarredReposOr404(config, vars["user"], URL)
+	if err != nil {
+		config.Log.UnableToRequest(err.Error())
+		respondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	repos := exportedRepos(userStarredRepos, config.DB.GetAllRepoTagsMap(vars["user"]))
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", exportDisposition(vars["user"], format))
+	w.WriteHeader(http.StatusOK)
+	switch format {
+	case "json":
+		err = writeJSONExport(w, repos)
+	case "csv":
+		err = writeCSVExport(w, repos)
+	case "yaml":
+		err = yaml.NewEncoder(w).Encode(repos)
+	}
+	if err != nil {
+		// The status was already sent, the client sees a truncated file
+		config.Log.UnableToRequest(err.Error())
+	}
+}
+
+// exportDisposition names the export file after the user, quoting or encoding the name as the header needs
+func exportDisposition(user, format string) string {
+	return mime.FormatMediaType("attachment", map[string]string{"filename": user + "-tags." + format})
+}
+
+// exportedRepos joins the starred repos with their tags
+func exportedRepos(userStarredRepos []model.StarredRepoRequest, tags map[int64][]string) []model.ExportedRepo {
+	repos := make([]model.ExportedRepo, len(userStarredRepos))
+	for i, repo := range userStarredRepos {
+		var starredAt *time.Time
+		if !repo.StarredAt.IsZero() {
+			starredAt = &userStarredRepos[i].StarredAt
+		}
+		repos[i] = model.ExportedRepo{
+			ID:          repo.ID,
+			FullName:    repo.FullName,
+			Name:        repo.Name,
+			Description: repo.Description,
+			URL:         repo.URL,
+			Language:    repo.Language,
+			Tags:        append([]string{}, tags[repo.ID]...),
+			Topics:      append([]string{}, repo.Topics...),
+			StarredAt:   starredAt,
+		}
+	}
+	return repos
+}
+
+// writeJSONExport writes the repos as a JSON array, one repo at a time
+func writeJSONExport(w http.ResponseWriter, repos []model.ExportedRepo) error {
+	if _, err := w.Write([]byte("[")); err != nil {
+		return err
+	}
+	for i, repo := range repos {
+		item, err := json.Marshal(repo)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			item = append([]byte(","), item...)
+		}
+		if _, err := w.Write(item); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write([]byte("]\n"))
+	return err
+}
+
+// writeCSVExport writes the repos as CSV with a header, the tags and topics are joined by ExportTagsSeparator
+func writeCSVExport(w http.ResponseWriter, repos []model.ExportedRepo) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportColumns); err != nil {
+		return err
+	}
+	for _, repo := range repos {
+		if err := writer.Write(csvRecord(repo)); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func csvRecord(repo model.ExportedRepo) []string {
+	starredAt := ""
+	if repo.StarredAt != nil {
+		starredAt = repo.StarredAt.Format(time.RFC3339)
+	}
+	return []string{
+		strconv.FormatInt(repo.ID, 10),
+		repo.FullName,
+		repo.Name,
+		repo.Description,
+		repo.URL,
+		repo.Language,
+		strings.Join(repo.Tags, ExportTagsSeparator),
+		strings.Join(repo.Topics, ExportTagsSeparator),
+		starredAt,
+	}
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/joaopmgd/github-tag-api/database"
+)
+
+func TestExportUserTags(t *testing.T) {
+	c := newTestConfig(t)
+	c.DB.InsertRepoTagsValue(database.RepoTag{UserID: "joaopmgd", RepoID: 10866521, TagName: "router"})
+	c.DB.InsertRepoTagsValue(database.RepoTag{UserID: "joaopmgd", RepoID: 10866521, TagName: "http"})
+	user := map[string]string{"user": "joaopmgd"}
+	tt := map[string]struct {
+		query          string
+		responseStatus int
+		contentType    string
+		responseBody   string
+	}{
+		"default_json": {"", http.StatusOK, "application/json", `[{"id":10866521,"full_name":"gorilla/mux","name":"mux","description":"A powerful HTTP router","url":"https://api.github.com/repos/gorilla/mux","language":"Go","tags":["router","http"],"topics":["go","router"]},` +
+			`{"id":724712,"full_name":"rust-lang/rust","name":"rust","description":"Empowering everyone to build reliable software","url":"https://api.github.com/repos/rust-lang/rust","language":"Rust","tags":[],"topics":[]}]` + "\n"},
+		"csv": {"?format=csv", http.StatusOK, "text/csv", "id,full_name,name,description,url,language,tags,topics,starred_at\n" +
+			"10866521,gorilla/mux,mux,A powerful HTTP router,https://api.github.com/repos/gorilla/mux,Go,router;http,go;router,\n" +
+			"724712,rust-lang/rust,rust,Empowering everyone to build reliable software,https://api.github.com/repos/rust-lang/rust,Rust,,,\n"},
+		"yaml": {"?format=yaml", http.StatusOK, "application/x-yaml", "- id: 10866521\n  full_name: gorilla/mux\n  name: mux\n  description: A powerful HTTP router\n  url: https://api.github.com/repos/gorilla/mux\n  language: Go\n  tags:\n  - router\n  - http\n  topics:\n  - go\n  - router\n" +
+			"- id: 724712\n  full_name: rust-lang/rust\n  name: rust\n  description: Empowering everyone to build reliable software\n  url: https://api.github.com/repos/rust-lang/rust\n  language: Rust\n  tags: []\n  topics: []\n"},
+		"invalid_format": {"?format=xml", http.StatusBadRequest, "application/json", `{"error":"Format must be one of: json, csv, yaml"}`},
+	}
+	for testName, tc := range tt {
+
+		response := executeHandlerTest(c, ExportUserTags, "GET", "/"+tc.query, "", user)
+
+		if response.Code != tc.responseStatus || response.Header().Get("Content-Type") != tc.contentType || response.Body.String() != tc.responseBody {
+			t.Errorf("\nTest %s\nGot Status %v, Content-Type %s and Body %s\nWant Status %v, Content-Type %s and Body %s",
+				testName, response.Code, response.Header().Get("Content-Type"), response.Body.String(), tc.responseStatus, tc.contentType, tc.responseBody)
+		}
+	}
+}
+
+func TestExportDisposition(t *testing.T) {
+	tt := map[string]struct {
+		user     string
+		format   string
+		filename string
+	}{
+		"plain":  {"joaopmgd", "csv", "joaopmgd-tags.csv"},
+		"quoted": {`jo"ao;x`, "json", `jo"ao;x-tags.json`},
+		"utf8":   {"joão", "yaml", "joão-tags.yaml"},
+	}
+	for testName, tc := range tt {
+
+		disposition := exportDisposition(tc.user, tc.format)
+
+		mediaType, params, err := mime.ParseMediaType(disposition)
+		if err != nil || mediaType != "attachment" || params["filename"] != tc.filename {
+			t.Errorf("\nTest %s\nGot %s parsed as %s %v and error %v\nWant attachment with filename %s",
+				testName, disposition, mediaType, params, err, tc.filename)
+		}
+	}
+}
+
+func TestExportedReposStarredAt(t *testing.T) {
+	starredAt := time.Date(2019, 8, 1, 12, 0, 0, 0, time.UTC)
+
+	repos := exportedRepos([]model.StarredRepoRequest{{ID: 1, StarredAt: starredAt}, {ID: 2}}, nil)
+
+	starred, _ := json.Marshal(repos[0])
+	unknown, _ := json.Marshal(repos[1])
+	if string(starred) != `{"id":1,"full_name":"","name":"","description":"","url":"","language":"","tags":[],"topics":[],"starred_at":"2019-08-01T12:00:00Z"}` || csvRecord(repos[0])[8] != "2019-08-01T12:00:00Z" {
+		t.Errorf("Got JSON %s and CSV %q for a starred repo, want the starred date in both", starred, csvRecord(repos[0])[8])
+	}
+	if string(unknown) != `{"id":2,"full_name":"","name":"","description":"","url":"","language":"","tags":[],"topics":[]}` || csvRecord(repos[1])[8] != "" {
+		t.Errorf("Got JSON %s and CSV %q without the starred date, want it left out of both", unknown, csvRecord(repos[1])[8])
+	}
+}
@@ -1,12 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/joaopmgd/github-tag-api/app/model"
-	"github.com/joaopmgd/github-tag-api/config"
 )
 
 func addLanguage(language string, tags []string) []string {
@@ -24,61 +24,141 @@ func addLanguage(language string, tags []string) []string {
 	return tags
 }
 
-func repoHasTag(tags []string, selectedTag string) bool {
+// Ways to compare the selected tags with the repo tags
+const (
+	tagModeExact     = "exact"
+	tagModePrefix    = "prefix"
+	tagModeSubstring = "substring"
+)
+
+// tagFilter selects repos by their tags, with any or all of the Tags and none of the Exclude ones
+type tagFilter struct {
+	Tags     []string
+	Exclude  []string
+	MatchAll bool
+	Mode     string
+}
+
+// newTagFilter reads the repeated tag and exclude query params, with the match (any or all) and mode (exact, prefix or substring)
+func newTagFilter(r *http.Request) (tagFilter, error) {
+	if err := r.ParseForm(); err != nil {
+		return tagFilter{}, err
+	}
+	filter := tagFilter{
+		Tags:    nonEmpty(r.Form["tag"]),
+		Exclude: nonEmpty(r.Form["exclude"]),
+		Mode:    r.FormValue("mode"),
+	}
+	switch r.FormValue("match") {
+	case "", "any":
+	case "all":
+		filter.MatchAll = true
+	default:
+		return tagFilter{}, errors.New("Match must be any or all")
+	}
+	switch filter.Mode {
+	case "":
+		filter.Mode = tagModeExact
+	case tagModeExact, tagModePrefix, tagModeSubstring:
+	default:
+		return tagFilter{}, errors.New("Mode must be exact, prefix or substring")
+	}
+	return filter, nil
+}
+
+func nonEmpty(values []string) []string {
+	var selected []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			selected = append(selected, value)
+		}
+	}
+	return selected
+}
+
+// matches tells if the repo tags pass the filter, every repo passes an empty filter
+func (f tagFilter) matches(tags []string) bool {
+	for _, excluded := range f.Exclude {
+		if repoHasTag(tags, excluded, f.Mode) {
+			return false
+		}
+	}
+	if len(f.Tags) == 0 {
+		return true
+	}
+	for _, selected := range f.Tags {
+		found := repoHasTag(tags, selected, f.Mode)
+		if found && !f.MatchAll {
+			return true
+		}
+		if !found && f.MatchAll {
+			return false
+		}
+	}
+	return f.MatchAll
+}
+
+func repoHasTag(tags []string, selectedTag string, mode string) bool {
 	if selectedTag != "" {
 		for _, tag := range tags {
-			if strings.Contains(tag, selectedTag) {
-				return true
+			switch mode {
+			case tagModeSubstring:
+				if strings.Contains(tag, selectedTag) {
+					return true
+				}
+			case tagModePrefix:
+				if strings.HasPrefix(tag, selectedTag) {
+					return true
+				}
+			default:
+				if tag == selectedTag {
+					return true
+				}
 			}
 		}
 	}
 	return false
 }
 
-func createMessageStarredReposSelectedTag(repos []model.StarredRepoRequest, tags map[int64][]string, selectedTag string) []model.StarredRepoTags {
+func createMessageStarredReposSelectedTag(repos []model.StarredRepoRequest, tags map[int64][]string, filter tagFilter) []model.StarredRepoTags {
 	starredRepos := []model.StarredRepoTags{}
 	for _, repo := range repos {
-		if selectedTag == "" || repoHasTag(tags[repo.ID], selectedTag) {
+		if filter.matches(tags[repo.ID]) {
 			starredRepos = append(starredRepos, model.StarredRepoTags{
 				ID:          repo.ID,
+				FullName:    repo.FullName,
 				Name:        repo.Name,
 				Description: repo.Description,
 				URL:         repo.URL,
 				Language:    repo.Language,
 				Tags:        tags[repo.ID],
+				Topics:      repo.Topics,
+				Stars:       repo.Stars,
+				StarredAt:   repo.StarredAt,
 			})
 		}
 	}
 	return starredRepos
 }
 
-// Paginate just picksup a slice from the Response, showing just the page Requested
-func paginate(config *config.Config, r *http.Request, starredRepos []model.StarredRepoTags) model.StarredRepoTagsResponse {
-	offset, err := strconv.Atoi(r.FormValue("offset"))
-	if err != nil {
-		offset = 0
-	}
-	limit, err := strconv.Atoi(r.FormValue("limit"))
-	if err != nil {
-		limit = 10
-	}
-	if offset*limit > len(starredRepos)-1 {
-		config.Log.PageIsBiggerThanRequestValues(strconv.Itoa(limit), strconv.Itoa(offset))
-		return model.StarredRepoTagsResponse{
-			StarredRepos:         []model.StarredRepoTags{},
-			PageNumber:           offset,
-			PageSize:             limit,
-			PropertiesTotalCount: len(starredRepos)}
-	}
-	if offset+limit > len(starredRepos) {
-		limit = len(starredRepos)
+// findStarredRepo finds the repo in the path, by its ID, its owner and name or only its name.
+// The candidates are every repo with the requested name, it is found only when there is a single one.
+func findStarredRepo(userStarredRepos []model.StarredRepoRequest, vars map[string]string) (model.StarredRepoRequest, []model.StarredRepoRequest) {
+	var candidates []model.StarredRepoRequest
+	for _, starred := range userStarredRepos {
+		switch {
+		case vars["owner"] != "":
+			if strings.EqualFold(starred.FullName, vars["owner"]+"/"+vars["name"]) {
+				candidates = append(candidates, starred)
+			}
+		case vars["repo"] == strconv.FormatInt(starred.ID, 10):
+			return starred, []model.StarredRepoRequest{starred}
+		case strings.EqualFold(starred.Name, vars["repo"]):
+			candidates = append(candidates, starred)
+		}
 	}
-	selectedRepos := (starredRepos)[offset*limit : (offset*limit)+limit]
-	starredReposResponseResponse := model.StarredRepoTagsResponse{
-		StarredRepos:         selectedRepos,
-		PageNumber:           offset,
-		PageSize:             limit,
-		PropertiesTotalCount: len(starredRepos),
+	if len(candidates) != 1 {
+		return model.StarredRepoRequest{}, candidates
 	}
-	return starredReposResponseResponse
+	return candidates[0], candidates
 }
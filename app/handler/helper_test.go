@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"strconv"
 	"testing"
@@ -35,18 +37,23 @@ func TestAddLanguage(t *testing.T) {
 func TestRepoHasTags(t *testing.T) {
 	tt := map[string]struct {
 		selectedTag string
+		mode        string
 		tags        []string
 		isPresent   bool
 	}{
-		"empty_tag_list":     {"golang", []string{}, false},
-		"empty_selected_tag": {"", []string{"golang", "java", "docker"}, false},
-		"tag_not_found":      {"golang", []string{"java", "docker"}, false},
-		"tag_found":          {"golang", []string{"golang", "java", "docker"}, true},
-		"nil_slice":          {"golang", nil, false},
+		"empty_tag_list":       {"golang", tagModeExact, []string{}, false},
+		"empty_selected_tag":   {"", tagModeExact, []string{"golang", "java", "docker"}, false},
+		"tag_not_found":        {"golang", tagModeExact, []string{"java", "docker"}, false},
+		"tag_found":            {"golang", tagModeExact, []string{"golang", "java", "docker"}, true},
+		"nil_slice":            {"golang", tagModeExact, nil, false},
+		"exact_not_substring":  {"go", tagModeExact, []string{"django", "mongo"}, false},
+		"substring_found":      {"go", tagModeSubstring, []string{"django", "mongo"}, true},
+		"prefix_not_substring": {"go", tagModePrefix, []string{"django", "mongo"}, false},
+		"prefix_found":         {"go", tagModePrefix, []string{"golang"}, true},
 	}
 	for testName, tc := range tt {
 
-		decision := repoHasTag(tc.tags, tc.selectedTag)
+		decision := repoHasTag(tc.tags, tc.selectedTag, tc.mode)
 
 		if decision != tc.isPresent {
 			t.Errorf("\nTest %s\nSelected tag '%s' and Initial Tags %s\nGot %s\nWant %s",
@@ -56,26 +63,104 @@ func TestRepoHasTags(t *testing.T) {
 }
 
 func TestCreateMessageStarredReposSelectedTag(t *testing.T) {
+	repos := []model.StarredRepoRequest{
+		{ID: 1, Name: "mux", Language: "Go"},
+		{ID: 2, Name: "django", Language: "Python"},
+	}
+	tags := map[int64][]string{1: {"golang", "router"}}
+	golang := tagFilter{Tags: []string{"golang"}, Mode: tagModeExact}
+	mux := model.StarredRepoTags{ID: 1, Name: "mux", Language: "Go", Tags: []string{"golang", "router"}}
+	django := model.StarredRepoTags{ID: 2, Name: "django", Language: "Python"}
 	tt := map[string]struct {
-		repos       []model.StarredRepoRequest
-		tags        map[int64][]string
-		selectedTag string
-		response    []model.StarredRepoTags
+		repos    []model.StarredRepoRequest
+		tags     map[int64][]string
+		filter   tagFilter
+		response []model.StarredRepoTags
 	}{
-		"empty_tag_list":         {"golang", []string{}, false},
-		"empty_repos":            {"", []string{"golang", "java", "docker"}, false},
-		"selected_tag_found":     {"golang", []string{"java", "docker"}, false},
-		"selected_tag_not_found": {"golang", []string{"golang", "java", "docker"}, true},
-		"nil_repos":              {"golang", nil, false},
-		"nil_tags":               {"golang", nil, false},
+		"empty_tag_list":         {repos, map[int64][]string{}, golang, []model.StarredRepoTags{}},
+		"empty_repos":            {[]model.StarredRepoRequest{}, tags, golang, []model.StarredRepoTags{}},
+		"selected_tag_found":     {repos, tags, golang, []model.StarredRepoTags{mux}},
+		"selected_tag_not_found": {repos, tags, tagFilter{Tags: []string{"java"}}, []model.StarredRepoTags{}},
+		"no_selected_tag":        {repos, tags, tagFilter{}, []model.StarredRepoTags{mux, django}},
+		"nil_repos":              {nil, tags, golang, []model.StarredRepoTags{}},
+		"nil_tags":               {repos, nil, golang, []model.StarredRepoTags{}},
+		"match_any":              {repos, tags, tagFilter{Tags: []string{"java", "router"}}, []model.StarredRepoTags{mux}},
+		"match_all_found":        {repos, tags, tagFilter{Tags: []string{"golang", "router"}, MatchAll: true}, []model.StarredRepoTags{mux}},
+		"match_all_not_found":    {repos, tags, tagFilter{Tags: []string{"golang", "java"}, MatchAll: true}, []model.StarredRepoTags{}},
+		"exclude_only":           {repos, tags, tagFilter{Exclude: []string{"router"}}, []model.StarredRepoTags{django}},
+		"exclude_selected":       {repos, tags, tagFilter{Tags: []string{"golang"}, Exclude: []string{"router"}}, []model.StarredRepoTags{}},
+		"substring_mode":         {repos, tags, tagFilter{Tags: []string{"lang"}, Mode: tagModeSubstring}, []model.StarredRepoTags{mux}},
 	}
 	for testName, tc := range tt {
 
-		decision := repoHasTag(tc.tags, tc.selectedTag)
+		response := createMessageStarredReposSelectedTag(tc.repos, tc.tags, tc.filter)
 
-		if decision != tc.isPresent {
-			t.Errorf("\nTest %s\nSelected tag '%s' and Initial Tags %s\nGot %s\nWant %s",
-				testName, tc.selectedTag, tc.tags, strconv.FormatBool(decision), strconv.FormatBool(tc.isPresent))
+		if !reflect.DeepEqual(response, tc.response) {
+			t.Errorf("\nTest %s\nFilter %+v\nGot %v\nWant %v",
+				testName, tc.filter, response, tc.response)
+		}
+	}
+}
+
+func TestNewTagFilter(t *testing.T) {
+	tt := map[string]struct {
+		query       string
+		filter      tagFilter
+		expectError bool
+	}{
+		"no_params":     {"", tagFilter{Mode: tagModeExact}, false},
+		"empty_tag":     {"tag=", tagFilter{Mode: tagModeExact}, false},
+		"repeated_tags": {"tag=go&tag=cli&match=all&exclude=archived", tagFilter{Tags: []string{"go", "cli"}, Exclude: []string{"archived"}, MatchAll: true, Mode: tagModeExact}, false},
+		"prefix_mode":   {"tag=go&mode=prefix", tagFilter{Tags: []string{"go"}, Mode: tagModePrefix}, false},
+		"invalid_match": {"tag=go&match=some", tagFilter{}, true},
+		"invalid_mode":  {"tag=go&mode=fuzzy", tagFilter{}, true},
+	}
+	for testName, tc := range tt {
+
+		filter, err := newTagFilter(httptest.NewRequest("GET", "/?"+tc.query, nil))
+
+		if (err != nil) != tc.expectError || !reflect.DeepEqual(filter, tc.filter) {
+			t.Errorf("\nTest %s\nGot %+v and error %v\nWant %+v and error %v",
+				testName, filter, err, tc.filter, tc.expectError)
 		}
 	}
 }
+
+func TestFindStarredRepo(t *testing.T) {
+	gorillaMux := model.StarredRepoRequest{ID: 1, FullName: "gorilla/mux", Name: "mux"}
+	otherMux := model.StarredRepoRequest{ID: 2, FullName: "other/mux", Name: "mux"}
+	rust := model.StarredRepoRequest{ID: 3, FullName: "rust-lang/rust", Name: "rust"}
+	repos := []model.StarredRepoRequest{gorillaMux, otherMux, rust}
+	tt := map[string]struct {
+		vars       map[string]string
+		repo       model.StarredRepoRequest
+		candidates []model.StarredRepoRequest
+	}{
+		"by_id":          {map[string]string{"repo": "2"}, otherMux, []model.StarredRepoRequest{otherMux}},
+		"by_full_name":   {map[string]string{"owner": "Gorilla", "name": "MUX"}, gorillaMux, []model.StarredRepoRequest{gorillaMux}},
+		"by_name":        {map[string]string{"repo": "rust"}, rust, []model.StarredRepoRequest{rust}},
+		"ambiguous_name": {map[string]string{"repo": "mux"}, model.StarredRepoRequest{}, []model.StarredRepoRequest{gorillaMux, otherMux}},
+		"not_found":      {map[string]string{"repo": "django"}, model.StarredRepoRequest{}, nil},
+	}
+	for testName, tc := range tt {
+
+		repo, candidates := findStarredRepo(repos, tc.vars)
+
+		if !reflect.DeepEqual(repo, tc.repo) || !reflect.DeepEqual(candidates, tc.candidates) {
+			t.Errorf("\nTest %s\nGot %v and candidates %v\nWant %v and candidates %v", testName, repo, candidates, tc.repo, tc.candidates)
+		}
+	}
+}
+
+func TestGetStarredRepoOr404Ambiguous(t *testing.T) {
+	c := newTestConfig(t)
+	repos := []model.StarredRepoRequest{{ID: 1, FullName: "gorilla/mux", Name: "mux"}, {ID: 2, FullName: "other/mux", Name: "mux"}}
+	rr := httptest.NewRecorder()
+
+	_, found := getStarredRepoOr404(c, rr, map[string]string{"repo": "mux"}, repos)
+
+	want := `{"error":"Repository name mux matches many starred repos, use the id or owner/name","candidates":["gorilla/mux","other/mux"]}`
+	if found || rr.Code != http.StatusConflict || rr.Body.String() != want {
+		t.Errorf("Got found %v, Status %v and Body %s\nWant Status %v and Body %s", found, rr.Code, rr.Body.String(), http.StatusConflict, want)
+	}
+}
@@ -0,0 +1,281 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/joaopmgd/github-tag-api/config"
+	"github.com/joaopmgd/github-tag-api/database"
+)
+
+// Strategies to import the tags of a repo that is already tagged
+const (
+	importStrategyMerge        = "merge"
+	importStrategyReplace      = "replace"
+	importStrategySkipExisting = "skip-existing"
+)
+
+// Status of each imported row
+const (
+	importStatusApplied   = "applied"
+	importStatusUnchanged = "unchanged"
+	importStatusSkipped   = "skipped"
+	importStatusInvalid   = "invalid"
+)
+
+// maxImportSize limits the size of the imported file
+const maxImportSize = 10 << 20
+
+// importRow is a row of the imported file, the repo is found by its ID or by its full name.
+// The ID is read from repo_id or from id, so an exported file can be imported back.
+type importRow struct {
+	RepoID   int64    `json:"repo_id"`
+	ID       int64    `json:"id"`
+	FullName string   `json:"full_name"`
+	Tags     []string `json:"tags"`
+
+	invalidID string
+}
+
+// ImportUserTags tags the starred repos of an user from a JSON or CSV file, reporting the result of each row
+func ImportUserTags(config *config.Config, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	// Validate strategy
+	strategy := r.URL.Query().Get("strategy")
+	if strategy == "" {
+		strategy = importStrategyMerge
+	}
+	if strategy != importStrategyMerge && strategy != importStrategyReplace && strategy != importStrategySkipExisting {
+		respondError(w, http.StatusBadRequest, "Strategy must be one of: merge, replace, skip-existing")
+		return
+	}
+
+	// Validate file
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	rows, err := readImportRows(r)
+	if err != nil {
+		config.Log.CouldNotParseRequestBody(err.Error())
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Validate URL
+	URL, err := config.GetStarredReposURL(vars)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userStarredRepos, err := getUserStarredReposOr404(config, vars["user"], URL)
+	if err != nil {
+		config.Log.UnableToRequest(err.Error())
+		respondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	response, changes := planImport(strategy, rows, userStarredRepos, config.DB.GetAllRepoTagsMap(vars["user"]))
+	if err := config.DB.BulkUpdateRepoTags(vars["user"], changes); err != nil {
+		config.Log.DatabaseError(err.Error())
+		respondError(w, http.StatusInternalServerError, "Could not change the tags")
+		return
+	}
+	countTagChanges(tagSourceImport, changes)
+	respondJSON(w, http.StatusOK, response)
+}
+
+// readImportRows decodes the rows from the body or from the multipart field named file.
+// The format param selects json or csv, otherwise it comes from the file extension or the content type.
+func readImportRows(r *http.Request) ([]importRow, error) {
+	body := io.Reader(r.Body)
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if contentType == "multipart/form-data" {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return nil, errors.New("Multipart body must have the file in a field named 'file'")
+		}
+		defer file.Close()
+		body = file
+		contentType, _, _ = mime.ParseMediaType(header.Header.Get("Content-Type"))
+		if extension := strings.TrimPrefix(filepath.Ext(header.Filename), "."); extension == "csv" || extension == "json" {
+			contentType = extension
+		}
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+		if strings.HasSuffix(contentType, "csv") {
+			format = "csv"
+		}
+	}
+	switch format {
+	case "json":
+		var rows []importRow
+		if err := json.NewDecoder(body).Decode(&rows); err != nil {
+			return nil, errors.New("Body must be a JSON list of rows with 'repo_id' or 'full_name' and the 'tags'")
+		}
+		return rows, nil
+	case "csv":
+		return readCSVImportRows(body)
+	}
+	return nil, errors.New("Format must be one of: json, csv")
+}
+
+// readCSVImportRows decodes a CSV with a header, which must have the repo_id or id or full_name column and the tags column
+func readCSVImportRows(body io.Reader) ([]importRow, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.New("Body is not a valid CSV: " + err.Error())
+	}
+	if len(records) == 0 {
+		return nil, errors.New("CSV must have a header with the repo_id or full_name and tags columns")
+	}
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	idColumn, hasID := columns["repo_id"]
+	if !hasID {
+		idColumn, hasID = columns["id"]
+	}
+	nameColumn, hasName := columns["full_name"]
+	tagsColumn, hasTags := columns["tags"]
+	if (!hasID && !hasName) || !hasTags {
+		return nil, errors.New("CSV must have a header with the repo_id or full_name and tags columns")
+	}
+	field := func(record []string, column int) string {
+		if column < len(record) {
+			return strings.TrimSpace(record[column])
+		}
+		return ""
+	}
+	rows := make([]importRow, 0, len(records)-1)
+	for _, record := range records[1:] {
+		var row importRow
+		if hasID && field(record, idColumn) != "" {
+			row.RepoID, err = strconv.ParseInt(field(record, idColumn), 10, 64)
+			if err != nil {
+				row.invalidID = field(record, idColumn)
+			}
+		}
+		if hasName {
+			row.FullName = field(record, nameColumn)
+		}
+		if tags := field(record, tagsColumn); tags != "" {
+			row.Tags = strings.Split(tags, ExportTagsSeparator)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// planImport resolves each row against the starred repos and their current tags, returning the report and the changes to apply.
+// Invalid rows are reported and skipped, the valid ones are still applied.
+func planImport(strategy string, rows []importRow, userStarredRepos []model.StarredRepoRequest, tags map[int64][]string) (model.ImportResponse, []database.RepoTagChange) {
+	reposByID := make(map[int64]model.StarredRepoRequest)
+	reposByName := make(map[string]model.StarredRepoRequest)
+	for _, repo := range userStarredRepos {
+		reposByID[repo.ID] = repo
+		if repo.FullName != "" {
+			reposByName[strings.ToLower(repo.FullName)] = repo
+		}
+	}
+	// Tags of each repo as they will be after the previous rows
+	repoTags := make(map[int64][]string)
+	for repoID, current := range tags {
+		repoTags[repoID] = current
+	}
+
+	response := model.ImportResponse{Strategy: strategy, Rows: make([]model.ImportRowResult, len(rows))}
+	var changes []database.RepoTagChange
+	for i, row := range rows {
+		if row.RepoID == 0 {
+			row.RepoID = row.ID
+		}
+		result := model.ImportRowResult{Row: i + 1, RepoID: row.RepoID, FullName: row.FullName, Added: []string{}, Removed: []string{}}
+		// The repo_id wins, the full_name finds the repos whose id is not among the stars and must agree with the id otherwise
+		repo, found := reposByID[row.RepoID]
+		mismatch := found && row.FullName != "" && !strings.EqualFold(repo.FullName, row.FullName)
+		if !found && row.FullName != "" {
+			repo, found = reposByName[strings.ToLower(row.FullName)]
+		}
+		if found && !mismatch {
+			result.RepoID, result.FullName = repo.ID, repo.FullName
+		}
+		switch {
+		case row.invalidID != "":
+			result.Status, result.Error = importStatusInvalid, "Invalid repo_id "+row.invalidID
+		case row.RepoID == 0 && row.FullName == "":
+			result.Status, result.Error = importStatusInvalid, "Row must have a repo_id or a full_name"
+		case mismatch:
+			result.Status, result.Error = importStatusInvalid, "Repository "+strconv.FormatInt(row.RepoID, 10)+" is "+repo.FullName+", not "+row.FullName
+		case !found && row.RepoID != 0:
+			result.Status, result.Error = importStatusInvalid, "Repository not found "+strconv.FormatInt(row.RepoID, 10)
+		case !found:
+			result.Status, result.Error = importStatusInvalid, "Repository not found "+row.FullName
+		case strategy == importStrategySkipExisting && len(repoTags[repo.ID]) > 0:
+			result.Status = importStatusSkipped
+		default:
+			current := repoTags[repo.ID]
+			imported := importedTags(row.Tags)
+			if strategy == importStrategyReplace {
+				result.Removed = missingTags(current, imported)
+			}
+			result.Added = missingTags(imported, current)
+			repoTags[repo.ID] = append(missingTags(current, result.Removed), result.Added...)
+			result.Status = importStatusApplied
+			if len(result.Added) == 0 && len(result.Removed) == 0 {
+				result.Status = importStatusUnchanged
+			} else {
+				changes = append(changes, database.RepoTagChange{RepoID: repo.ID, Language: repo.Language, Add: result.Added, Remove: result.Removed})
+			}
+		}
+		switch result.Status {
+		case importStatusApplied:
+			response.Applied++
+		case importStatusUnchanged:
+			response.Unchanged++
+		case importStatusSkipped:
+			response.Skipped++
+		case importStatusInvalid:
+			response.Invalid++
+		}
+		response.Rows[i] = result
+	}
+	return response, changes
+}
+
+// importedTags trims the tags of a row, dropping the empty and repeated ones
+func importedTags(tags []string) []string {
+	var selected []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			selected = append(selected, tag)
+		}
+	}
+	return selected
+}
+
+// missingTags returns the tags that are not in others, keeping their order
+func missingTags(tags, others []string) []string {
+	missing := []string{}
+	for _, tag := range tags {
+		if !repoHasTag(others, tag, tagModeExact) {
+			missing = append(missing, tag)
+		}
+	}
+	return missing
+}
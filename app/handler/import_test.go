@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/joaopmgd/github-tag-api/database"
+)
+
+func TestImportUserTags(t *testing.T) {
+	user := map[string]string{"user": "joaopmgd"}
+	rows := `[{"repo_id":10866521,"tags":["http","router"]},{"full_name":"Rust-Lang/rust","tags":["compiler"]},{"full_name":"gorilla/nothing","tags":["x"]}]`
+	tt := map[string]struct {
+		query          string
+		contentType    string
+		body           string
+		responseStatus int
+		responseBody   string
+		tags           map[int64][]string
+	}{
+		"merge_json": {"", "application/json", rows, http.StatusOK,
+			`{"strategy":"merge","applied":2,"unchanged":0,"skipped":0,"invalid":1,"rows":[` +
+				`{"row":1,"repo_id":10866521,"full_name":"gorilla/mux","status":"applied","added":["http"],"removed":[]},` +
+				`{"row":2,"repo_id":724712,"full_name":"rust-lang/rust","status":"applied","added":["compiler"],"removed":[]},` +
+				`{"row":3,"full_name":"gorilla/nothing","status":"invalid","added":[],"removed":[],"error":"Repository not found gorilla/nothing"}]}`,
+			map[int64][]string{10866521: {"router", "go", "http"}, 724712: {"compiler"}}},
+		"replace_csv": {"?strategy=replace", "text/csv", "id,full_name,tags\n10866521,,http;router\nabc,,x\n", http.StatusOK,
+			`{"strategy":"replace","applied":1,"unchanged":0,"skipped":0,"invalid":1,"rows":[` +
+				`{"row":1,"repo_id":10866521,"full_name":"gorilla/mux","status":"applied","added":["http"],"removed":["go"]},` +
+				`{"row":2,"status":"invalid","added":[],"removed":[],"error":"Invalid repo_id abc"}]}`,
+			map[int64][]string{10866521: {"router", "http"}}},
+		"skip_existing": {"?strategy=skip-existing", "application/json", rows, http.StatusOK,
+			`{"strategy":"skip-existing","applied":1,"unchanged":0,"skipped":1,"invalid":1,"rows":[` +
+				`{"row":1,"repo_id":10866521,"full_name":"gorilla/mux","status":"skipped","added":[],"removed":[]},` +
+				`{"row":2,"repo_id":724712,"full_name":"rust-lang/rust","status":"applied","added":["compiler"],"removed":[]},` +
+				`{"row":3,"full_name":"gorilla/nothing","status":"invalid","added":[],"removed":[],"error":"Repository not found gorilla/nothing"}]}`,
+			map[int64][]string{10866521: {"router", "go"}, 724712: {"compiler"}}},
+		"id_and_name": {"", "application/json", `[{"repo_id":1,"full_name":"rust-lang/rust","tags":["compiler"]},{"repo_id":10866521,"full_name":"rust-lang/rust","tags":["x"]}]`, http.StatusOK,
+			`{"strategy":"merge","applied":1,"unchanged":0,"skipped":0,"invalid":1,"rows":[` +
+				`{"row":1,"repo_id":724712,"full_name":"rust-lang/rust","status":"applied","added":["compiler"],"removed":[]},` +
+				`{"row":2,"repo_id":10866521,"full_name":"rust-lang/rust","status":"invalid","added":[],"removed":[],"error":"Repository 10866521 is gorilla/mux, not rust-lang/rust"}]}`,
+			map[int64][]string{10866521: {"router", "go"}, 724712: {"compiler"}}},
+		"invalid_strategy": {"?strategy=overwrite", "application/json", rows, http.StatusBadRequest, `{"error":"Strategy must be one of: merge, replace, skip-existing"}`,
+			map[int64][]string{10866521: {"router", "go"}}},
+		"invalid_csv_header": {"", "text/csv", "name,labels\nmux,http\n", http.StatusBadRequest, `{"error":"CSV must have a header with the repo_id or full_name and tags columns"}`,
+			map[int64][]string{10866521: {"router", "go"}}},
+	}
+	for testName, tc := range tt {
+		c := newTestConfig(t)
+		c.DB.InsertRepoTagsValue(database.RepoTag{UserID: "joaopmgd", RepoID: 10866521, TagName: "router"})
+		c.DB.InsertRepoTagsValue(database.RepoTag{UserID: "joaopmgd", RepoID: 10866521, TagName: "go"})
+		req := httptest.NewRequest("POST", "/"+tc.query, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", tc.contentType)
+		rr := httptest.NewRecorder()
+
+		ImportUserTags(c, rr, mux.SetURLVars(req, user))
+
+		tags := c.DB.GetAllRepoTagsMap("joaopmgd")
+		if rr.Code != tc.responseStatus || strings.TrimSpace(rr.Body.String()) != tc.responseBody || !reflect.DeepEqual(tags, tc.tags) {
+			t.Errorf("\nTest %s\nGot Status %v, Body %s and tags %v\nWant Status %v, Body %s and tags %v",
+				testName, rr.Code, rr.Body.String(), tags, tc.responseStatus, tc.responseBody, tc.tags)
+		}
+	}
+}
+
+func TestImportUserTagsMultipart(t *testing.T) {
+	c := newTestConfig(t)
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	file, _ := writer.CreateFormFile("file", "backup.csv")
+	file.Write([]byte("full_name,tags\ngorilla/mux,router\n"))
+	writer.Close()
+	req := httptest.NewRequest("POST", "/", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rr := httptest.NewRecorder()
+
+	ImportUserTags(c, rr, mux.SetURLVars(req, map[string]string{"user": "joaopmgd"}))
+
+	if tags := c.DB.GetAllRepoTagsMap("joaopmgd"); rr.Code != http.StatusOK || !reflect.DeepEqual(tags, map[int64][]string{10866521: {"router"}}) {
+		t.Errorf("Got Status %v, Body %s and tags %v, want mux tagged router", rr.Code, rr.Body.String(), tags)
+	}
+}
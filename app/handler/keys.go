@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/joaopmgd/github-tag-api/auth"
+	"github.com/joaopmgd/github-tag-api/config"
+	"github.com/joaopmgd/github-tag-api/database"
+)
+
+// GetAPIKeys lists the API keys of an user that were not revoked, without the keys themselves
+func GetAPIKeys(config *config.Config, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	keys := []model.APIKey{}
+	now := time.Now()
+	for _, key := range config.DB.GetAPIKeys(vars["user"]) {
+		keys = append(keys, toAPIKey(key, now))
+	}
+	respondJSON(w, http.StatusOK, keys)
+}
+
+// CreateAPIKey issues a new API key for an user, the key is only shown in this response
+func CreateAPIKey(config *config.Config, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	// Validate body
+	var keyData model.APIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&keyData); err != nil {
+		config.Log.CouldNotParseRequestBody(err.Error())
+		respondError(w, http.StatusBadRequest, "Body must be a JSON with the key 'name', 'scopes' and 'expires_at'")
+		return
+	}
+	if len(keyData.Scopes) == 0 {
+		keyData.Scopes = []string{auth.ScopeRead}
+	}
+	for _, scope := range keyData.Scopes {
+		if !auth.ValidScope(scope) {
+			respondError(w, http.StatusBadRequest, "Invalid scope "+scope+", it must be read, write or admin")
+			return
+		}
+	}
+	now := time.Now()
+	if keyData.ExpiresAt != nil && !keyData.ExpiresAt.After(now) {
+		respondError(w, http.StatusBadRequest, "The expires_at must be in the future")
+		return
+	}
+
+	key, prefix, err := auth.GenerateKey()
+	if err != nil {
+		config.Log.AuthenticationError(err.Error())
+		respondError(w, http.StatusInternalServerError, "Could not create the API key")
+		return
+	}
+	stored := database.APIKey{
+		UserID:    vars["user"],
+		Name:      keyData.Name,
+		Prefix:    prefix,
+		Hash:      auth.Hash(key),
+		Scopes:    strings.Join(keyData.Scopes, ","),
+		ExpiresAt: keyData.ExpiresAt,
+	}
+	if err := config.DB.InsertAPIKey(&stored); err != nil {
+		config.Log.DatabaseError(err.Error())
+		respondError(w, http.StatusInternalServerError, "Could not create the API key")
+		return
+	}
+	config.Log.APIKeyCreated(vars["user"], stored.ID, stored.Scopes)
+	respondJSON(w, http.StatusCreated, model.APIKeyCreated{APIKey: toAPIKey(stored, now), Key: key})
+}
+
+// RevokeAPIKey revokes an API key of an user, it is not accepted anymore
+func RevokeAPIKey(config *config.Config, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["key"], 10, 32)
+	if err != nil || !config.DB.RevokeAPIKey(vars["user"], uint(id)) {
+		respondError(w, http.StatusNotFound, "API key not found "+vars["key"])
+		return
+	}
+	config.Log.APIKeyRevoked(vars["user"], uint(id))
+	respondJSON(w, http.StatusOK, model.ResponseOK{Message: "API key revoked"})
+}
+
+// toAPIKey describes a stored API key, expired if its expiration is not after now
+func toAPIKey(key database.APIKey, now time.Time) model.APIKey {
+	return model.APIKey{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     auth.ParseScopes(key.Scopes),
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		Expired:    key.ExpiresAt != nil && !now.Before(*key.ExpiresAt),
+	}
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/joaopmgd/github-tag-api/auth"
+	"github.com/joaopmgd/github-tag-api/config"
+)
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	c := newAuthTestConfig(t)
+	c.Auth = auth.NewKeyAuthenticator(c.DB, []string{"root"}, c.Auth)
+	user := map[string]string{"user": "joaopmgd"}
+	repo := map[string]string{"user": "joaopmgd", "repo": "10866521"}
+
+	// createKey issues a key with the scopes for joaopmgd
+	createKey := func(scopes string) model.APIKeyCreated {
+		rr := executeAuthorizedTest(c, AuthorizeKeyManager, CreateAPIKey, "POST", "owner-token", `{"name": "cli", "scopes": `+scopes+`}`, user)
+		var created model.APIKeyCreated
+		json.NewDecoder(rr.Body).Decode(&created)
+		if rr.Code != http.StatusCreated || !strings.HasPrefix(created.Key, auth.KeyPrefix) || !strings.HasPrefix(created.Key, created.Prefix) {
+			t.Fatalf("Got Status %v and key %+v\nWant Status %v and a new key", rr.Code, created, http.StatusCreated)
+		}
+		return created
+	}
+	writeKey := createKey(`["write"]`)
+	readKey := createKey(`[]`)
+	if strings.Join(readKey.Scopes, ",") != auth.ScopeRead {
+		t.Errorf("Got scopes %v, want the default read scope", readKey.Scopes)
+	}
+
+	steps := []struct {
+		name           string
+		check          func(*config.Config, http.ResponseWriter, *http.Request) bool
+		handler        func(*config.Config, http.ResponseWriter, *http.Request)
+		method         string
+		credential     string
+		body           string
+		vars           map[string]string
+		responseStatus int
+		responseBody   string
+	}{
+		{"create_anonymous", AuthorizeKeyManager, CreateAPIKey, "POST", "", `{}`, user, http.StatusUnauthorized, `{"error":"Authentication required"}`},
+		{"create_other_user", AuthorizeKeyManager, CreateAPIKey, "POST", "other-token", `{}`, user, http.StatusForbidden, `{"error":"Only joaopmgd or an admin can manage these keys"}`},
+		{"create_without_admin_scope", AuthorizeKeyManager, CreateAPIKey, "POST", writeKey.Key, `{}`, user, http.StatusForbidden, `{"error":"The credentials need the admin scope"}`},
+		{"invalid_scope", AuthorizeKeyManager, CreateAPIKey, "POST", "owner-token", `{"scopes": ["delete"]}`, user, http.StatusBadRequest, `{"error":"Invalid scope delete, it must be read, write or admin"}`},
+		{"past_expiration", AuthorizeKeyManager, CreateAPIKey, "POST", "owner-token", `{"expires_at": "2001-01-01T00:00:00Z"}`, user, http.StatusBadRequest, `{"error":"The expires_at must be in the future"}`},
+		{"invalid_body", AuthorizeKeyManager, CreateAPIKey, "POST", "owner-token", `scopes`, user, http.StatusBadRequest, `{"error":"Body must be a JSON with the key 'name', 'scopes' and 'expires_at'"}`},
+		{"tag_with_write_key", AuthorizeOwner, PostTagStarredRepo, "POST", writeKey.Key, `{"tag": "router"}`, repo, http.StatusOK, `{"Message":"Tag added"}`},
+		{"tag_with_read_key", AuthorizeOwner, PostTagStarredRepo, "POST", readKey.Key, `{"tag": "http"}`, repo, http.StatusForbidden, `{"error":"The credentials need the write scope"}`},
+		{"revoke_other_user", AuthorizeKeyManager, RevokeAPIKey, "DELETE", "other-token", "", map[string]string{"user": "joaopmgd", "key": strconv.Itoa(int(writeKey.ID))}, http.StatusForbidden, `{"error":"Only joaopmgd or an admin can manage these keys"}`},
+		{"revoke", AuthorizeKeyManager, RevokeAPIKey, "DELETE", "admin-token", "", map[string]string{"user": "joaopmgd", "key": strconv.Itoa(int(writeKey.ID))}, http.StatusOK, `{"Message":"API key revoked"}`},
+		{"revoked_key", AuthorizeOwner, PostTagStarredRepo, "POST", writeKey.Key, `{"tag": "http"}`, repo, http.StatusUnauthorized, `{"error":"Invalid credentials"}`},
+		{"revoke_again", AuthorizeKeyManager, RevokeAPIKey, "DELETE", "owner-token", "", map[string]string{"user": "joaopmgd", "key": strconv.Itoa(int(writeKey.ID))}, http.StatusNotFound, `{"error":"API key not found ` + strconv.Itoa(int(writeKey.ID)) + `"}`},
+		{"revoke_invalid_id", AuthorizeKeyManager, RevokeAPIKey, "DELETE", "owner-token", "", map[string]string{"user": "joaopmgd", "key": "abc"}, http.StatusNotFound, `{"error":"API key not found abc"}`},
+	}
+	for _, step := range steps {
+
+		rr := executeAuthorizedTest(c, step.check, step.handler, step.method, step.credential, step.body, step.vars)
+
+		if rr.Code != step.responseStatus || rr.Body.String() != step.responseBody {
+			t.Fatalf("\nTest %s\nGot Status %v and Body %s\nWant Status %v and Body %s",
+				step.name, rr.Code, rr.Body.String(), step.responseStatus, step.responseBody)
+		}
+	}
+
+	rr := executeAuthorizedTest(c, AuthorizeKeyManager, GetAPIKeys, "GET", "owner-token", "", user)
+	var keys []model.APIKey
+	json.NewDecoder(rr.Body).Decode(&keys)
+	if rr.Code != http.StatusOK || len(keys) != 1 || keys[0].ID != readKey.ID || keys[0].Prefix != readKey.Prefix || keys[0].LastUsedAt == nil || keys[0].Expired {
+		t.Errorf("Got Status %v and keys %+v\nWant only the read key, marked as used", rr.Code, keys)
+	}
+	if strings.Contains(rr.Body.String(), readKey.Key) {
+		t.Errorf("Got the key itself in the list %s", rr.Body.String())
+	}
+}
+
+func TestAPIKeysWithoutAuthentication(t *testing.T) {
+	c := newTestConfig(t)
+
+	rr := executeAuthorizedTest(c, AuthorizeKeyManager, CreateAPIKey, "POST", "", `{}`, map[string]string{"user": "joaopmgd"})
+
+	if rr.Code != http.StatusNotImplemented || rr.Body.String() != `{"error":"API keys need the authentication enabled"}` {
+		t.Errorf("Got Status %v and Body %s\nWant Status %v", rr.Code, rr.Body.String(), http.StatusNotImplemented)
+	}
+}
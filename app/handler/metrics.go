@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"github.com/joaopmgd/github-tag-api/database"
+	"github.com/joaopmgd/github-tag-api/metrics"
+)
+
+// Sources of the tag changes counted by the metrics
+const (
+	tagSourceUser   = "user"
+	tagSourceBulk   = "bulk"
+	tagSourceImport = "import"
+	tagSourceAuto   = "auto"
+)
+
+// countTagChanges counts the tags added and removed by the applied changes
+func countTagChanges(source string, changes []database.RepoTagChange) {
+	added, removed := 0, 0
+	for _, change := range changes {
+		added += len(change.Add)
+		removed += len(change.Remove)
+	}
+	metrics.AddTags(source, added)
+	metrics.RemoveTags(source, removed)
+}
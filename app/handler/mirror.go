@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/joaopmgd/github-tag-api/config"
+	"github.com/joaopmgd/github-tag-api/database"
+	"github.com/joaopmgd/github-tag-api/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// mirrorEnabled tells if the starred repos are read from the local mirror
+func mirrorEnabled(config *config.Config) bool {
+	return config.MirrorSyncInterval > 0
+}
+
+// syncStarredRepos requests the starred repos from Github, creating their automatic tags when they changed, and updates the user mirror when it is enabled
+func syncStarredRepos(config *config.Config, user, URL string) ([]model.StarredRepoRequest, error) {
+	userStarredRepos, changed, err := config.Github.GetStarredReposChanged(config.Context(), user, URL)
+	if err != nil {
+		if mirrorEnabled(config) {
+			config.Log.MirrorSyncError(user, err.Error())
+			config.DB.SaveMirrorError(user, err.Error())
+		}
+		return nil, err
+	}
+	if changed {
+		applyAutoTags(config, user, userStarredRepos)
+	}
+	if !mirrorEnabled(config) {
+		return userStarredRepos, nil
+	}
+	if err := config.DB.SaveStarredRepos(user, toMirror(userStarredRepos), time.Now()); err != nil {
+		config.Log.MirrorSyncError(user, err.Error())
+		return userStarredRepos, nil
+	}
+	config.Log.MirrorSynced(user, len(userStarredRepos))
+	return userStarredRepos, nil
+}
+
+// readMirror recovers the mirrored starred repos, false if the user was never synced
+func readMirror(config *config.Config, user string) ([]model.StarredRepoRequest, bool) {
+	mirrored, found := config.DB.GetStarredRepos(user)
+	if !found {
+		return nil, false
+	}
+	userStarredRepos := make([]model.StarredRepoRequest, len(mirrored))
+	for i, repo := range mirrored {
+		userStarredRepos[i] = model.StarredRepoRequest{
+			ID:          repo.RepoID,
+			FullName:    repo.FullName,
+			Name:        repo.Name,
+			Description: repo.Description,
+			URL:         repo.URL,
+			Language:    repo.Language,
+			Topics:      splitTopics(repo.Topics),
+			Stars:       repo.Stars,
+			StarredAt:   repo.StarredAt,
+		}
+	}
+	return userStarredRepos, true
+}
+
+func toMirror(userStarredRepos []model.StarredRepoRequest) []database.StarredRepo {
+	mirrored := make([]database.StarredRepo, len(userStarredRepos))
+	for i, repo := range userStarredRepos {
+		mirrored[i] = database.StarredRepo{
+			RepoID:      repo.ID,
+			FullName:    repo.FullName,
+			Name:        repo.Name,
+			Description: repo.Description,
+			URL:         repo.URL,
+			Language:    repo.Language,
+			Topics:      strings.Join(repo.Topics, database.TopicsSeparator),
+			Stars:       repo.Stars,
+			StarredAt:   repo.StarredAt,
+		}
+	}
+	return mirrored
+}
+
+func splitTopics(topics string) []string {
+	if topics == "" {
+		return nil
+	}
+	return strings.Split(topics, database.TopicsSeparator)
+}
+
+// SyncMirror syncs the starred repos of every mirrored user
+func SyncMirror(config *config.Config) {
+	for _, user := range config.DB.GetMirrorUsers() {
+		URL, err := config.GetStarredReposURL(map[string]string{"user": user.UserID})
+		if err != nil {
+			continue
+		}
+		ctx, span := tracing.Tracer().Start(context.Background(), "mirror sync",
+			trace.WithAttributes(attribute.String("github.user", user.UserID)))
+		syncStarredRepos(config.WithContext(ctx), user.UserID, URL)
+		span.End()
+	}
+}
+
+// RunMirrorSync syncs every mirrored user on each MirrorSyncInterval, until stop is closed
+func RunMirrorSync(config *config.Config, stop <-chan struct{}) {
+	ticker := time.NewTicker(config.MirrorSyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			SyncMirror(config)
+		}
+	}
+}
+
+// mirrorStatus details how stale the mirror of each user is
+func mirrorStatus(config *config.Config) []model.MirrorStatus {
+	if !mirrorEnabled(config) {
+		return nil
+	}
+	now := time.Now()
+	mirrors := []model.MirrorStatus{}
+	for _, user := range config.DB.GetMirrorUsers() {
+		mirrors = append(mirrors, model.MirrorStatus{
+			User:         user.UserID,
+			SyncedAt:     user.SyncedAt,
+			StaleSeconds: int64(now.Sub(user.SyncedAt).Seconds()),
+			Error:        user.SyncError,
+		})
+	}
+	return mirrors
+}
+
+// SyncUserStarredRepos syncs the mirror of an user right away, registering the user if needed
+func SyncUserStarredRepos(config *config.Config, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if !mirrorEnabled(config) {
+		respondError(w, http.StatusBadRequest, "The starred repos mirror is disabled")
+		return
+	}
+
+	// Validate URL
+	URL, err := config.GetStarredReposURL(vars)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, err := syncStarredRepos(config, vars["user"], URL); err != nil {
+		config.Log.UnableToRequest(err.Error())
+		respondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	respondJSON(w, http.StatusOK, model.ResponseOK{Message: "Starred repos synced"})
+}
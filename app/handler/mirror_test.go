@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/joaopmgd/github-tag-api/app/model"
+)
+
+func TestMirrorKeepsWorkingWithoutGithub(t *testing.T) {
+	c := newTestConfig(t)
+	c.MirrorSyncInterval = time.Hour
+	user := map[string]string{"user": "joaopmgd"}
+	repo := map[string]string{"user": "joaopmgd", "repo": "10866521"}
+
+	if response := executeHandlerTest(c, SyncUserStarredRepos, "POST", "/", "", user); response.Code != http.StatusOK {
+		t.Fatalf("Sync got status %v and body %s", response.Code, response.Body.String())
+	}
+	if response := executeHandlerTest(c, SyncUserStarredRepos, "POST", "/", "", map[string]string{"user": "nobody"}); response.Code != http.StatusNotFound {
+		t.Errorf("Sync of an unknown user got status %v, want %v", response.Code, http.StatusNotFound)
+	}
+
+	// Github is down from now on
+	c.Endpoints.GithubURL = "http://127.0.0.1:0"
+	c.Github.InvalidateStarredRepos("joaopmgd")
+	SyncMirror(c)
+
+	if response := executeHandlerTest(c, PostTagStarredRepo, "POST", "/", `{"tag": "router"}`, repo); response.Code != http.StatusOK {
+		t.Errorf("Tagging from the mirror got status %v and body %s", response.Code, response.Body.String())
+	}
+	response := executeHandlerTest(c, GetAllStarredRepos, "GET", "/", "", user)
+	var starred model.StarredRepoTagsResponse
+	json.NewDecoder(response.Body).Decode(&starred)
+	if response.Code != http.StatusOK || starred.PropertiesTotalCount != len(testStarredRepos) {
+		t.Errorf("Listing from the mirror got status %v and %d repos", response.Code, starred.PropertiesTotalCount)
+	}
+
+	response = executeHandlerTest(c, HealthStatus, "GET", "/", "", nil)
+	var health model.AppHealthStatus
+	json.NewDecoder(response.Body).Decode(&health)
+	if len(health.Mirrors) != 1 || health.Mirrors[0].User != "joaopmgd" || health.Mirrors[0].Error == "" {
+		t.Errorf("Health got mirrors %+v, want joaopmgd with the last sync error", health.Mirrors)
+	}
+}
+
+func TestMirrorReadSkipsAutoTags(t *testing.T) {
+	c := newTestConfig(t)
+	c.MirrorSyncInterval = time.Hour
+	user := map[string]string{"user": "joaopmgd"}
+	executeHandlerTest(c, SyncUserStarredRepos, "POST", "/", "", user)
+
+	// Reading the mirror does not create the automatic tags, only a sync of a changed list does
+	c.AutoTagTopics = true
+	executeHandlerTest(c, GetAllStarredRepos, "GET", "/", "", user)
+
+	if tags := c.DB.GetAllRepoTagsMap("joaopmgd"); len(tags) != 0 {
+		t.Errorf("Got tags %v after reading the mirror, want none", tags)
+	}
+	c.Github.InvalidateStarredRepos("joaopmgd")
+	executeHandlerTest(c, SyncUserStarredRepos, "POST", "/", "", user)
+	if tags := c.DB.GetAllRepoTagsMap("joaopmgd"); !reflect.DeepEqual(tags, map[int64][]string{10866521: {"go", "router"}}) {
+		t.Errorf("Got tags %v after syncing, want the topic tags", tags)
+	}
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/joaopmgd/github-tag-api/config"
+)
+
+// maxPageSize is the biggest limit accepted by the paginated lists
+const maxPageSize = 100
+
+// pageRequest is the requested page, Start is the index of its first item
+type pageRequest struct {
+	Start int
+	Limit int
+}
+
+// pageCursor is the content of the opaque cursors
+type pageCursor struct {
+	Start int `json:"s"`
+}
+
+// newPageRequest reads the limit and either the cursor or the offset query params.
+// The offset is a page number, 0 by default, and the limit goes from 1 to 100, 10 by default.
+func newPageRequest(r *http.Request) (pageRequest, error) {
+	limit, err := intParam(r, "limit", 10, 1, maxPageSize)
+	if err != nil {
+		return pageRequest{}, err
+	}
+	cursor := r.FormValue("cursor")
+	if cursor == "" {
+		offset, err := intParam(r, "offset", 0, 0, math.MaxInt32/maxPageSize)
+		if err != nil {
+			return pageRequest{}, err
+		}
+		return pageRequest{Start: offset * limit, Limit: limit}, nil
+	}
+	if r.FormValue("offset") != "" {
+		return pageRequest{}, errors.New("Use either the cursor or the offset param")
+	}
+	start, err := decodeCursor(cursor)
+	if err != nil {
+		return pageRequest{}, err
+	}
+	return pageRequest{Start: start, Limit: limit}, nil
+}
+
+// bounds returns the slice bounds of the page, it is empty when the page is past the end
+func (p pageRequest) bounds(total int) (int, int) {
+	if p.Start >= total {
+		return total, total
+	}
+	end := p.Start + p.Limit
+	if end > total {
+		end = total
+	}
+	return p.Start, end
+}
+
+// number is the page number, when the cursor does not start at a page boundary it is the page of its first item
+func (p pageRequest) number() int {
+	return p.Start / p.Limit
+}
+
+// links creates the URLs of the pages around this one, keeping every other query param of the request
+func (p pageRequest) links(r *http.Request, total int) (model.PageLinks, string) {
+	var links model.PageLinks
+	var header []string
+	add := func(rel string, start int) string {
+		link := pageURL(r, start)
+		header = append(header, "<"+link+`>; rel="`+rel+`"`)
+		return link
+	}
+	if p.Start+p.Limit < total {
+		links.Next = add("next", p.Start+p.Limit)
+	}
+	if p.Start > 0 {
+		previous := p.Start - p.Limit
+		if previous < 0 {
+			previous = 0
+		}
+		// Past the end the previous page is the last one
+		if p.Start >= total && total > 0 {
+			previous = (total - 1) / p.Limit * p.Limit
+		}
+		links.Prev = add("prev", previous)
+	}
+	if total > 0 {
+		add("first", 0)
+		add("last", (total-1)/p.Limit*p.Limit)
+	}
+	return links, strings.Join(header, ", ")
+}
+
+// paginate picks the requested page of the list, setting the RFC 5988 Link header with the pages around it
+func paginate(config *config.Config, w http.ResponseWriter, r *http.Request, p pageRequest, total int) (int, int, model.PageLinks) {
+	if p.Start > 0 && p.Start >= total {
+		config.Log.PageIsBiggerThanRequestValues(strconv.Itoa(p.Limit), strconv.Itoa(p.Start))
+	}
+	links, header := p.links(r, total)
+	if header != "" {
+		w.Header().Set("Link", header)
+	}
+	start, end := p.bounds(total)
+	return start, end, links
+}
+
+// pageURL is the request URL pointing to the page starting at start
+func pageURL(r *http.Request, start int) string {
+	query := r.URL.Query()
+	query.Del("offset")
+	query.Set("cursor", encodeCursor(start))
+	u := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	return u.String()
+}
+
+func encodeCursor(start int) string {
+	cursor, _ := json.Marshal(pageCursor{Start: start})
+	return base64.RawURLEncoding.EncodeToString(cursor)
+}
+
+func decodeCursor(cursor string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	var decoded pageCursor
+	if err == nil {
+		err = json.Unmarshal(data, &decoded)
+	}
+	// The start is bounded as the offset, so adding the limit to it never overflows
+	if err != nil || decoded.Start < 0 || decoded.Start > math.MaxInt32 {
+		return 0, errors.New("Param cursor is not valid")
+	}
+	return decoded.Start, nil
+}
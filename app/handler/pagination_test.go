@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/joaopmgd/github-tag-api/app/model"
+)
+
+func TestPaginate(t *testing.T) {
+	c := newTestConfig(t)
+	tt := map[string]struct {
+		query       string
+		total       int
+		start       int
+		end         int
+		links       model.PageLinks
+		header      string
+		expectError bool
+	}{
+		"first_page": {"?limit=2", 5, 0, 2, model.PageLinks{Next: "/starred?cursor=" + encodeCursor(2) + "&limit=2"},
+			`</starred?cursor=` + encodeCursor(2) + `&limit=2>; rel="next", </starred?cursor=` + encodeCursor(0) + `&limit=2>; rel="first", </starred?cursor=` + encodeCursor(4) + `&limit=2>; rel="last"`, false},
+		"offset_is_a_page": {"?offset=1&limit=2&sort=name", 5, 2, 4, model.PageLinks{
+			Next: "/starred?cursor=" + encodeCursor(4) + "&limit=2&sort=name",
+			Prev: "/starred?cursor=" + encodeCursor(0) + "&limit=2&sort=name",
+		}, "", false},
+		"last_page":       {"?offset=2&limit=2", 5, 4, 5, model.PageLinks{Prev: "/starred?cursor=" + encodeCursor(2) + "&limit=2"}, "", false},
+		"cursor":          {"?cursor=" + encodeCursor(3) + "&limit=2", 5, 3, 5, model.PageLinks{Prev: "/starred?cursor=" + encodeCursor(1) + "&limit=2"}, "", false},
+		"unaligned_start": {"?cursor=" + encodeCursor(1) + "&limit=2", 5, 1, 3, model.PageLinks{Next: "/starred?cursor=" + encodeCursor(3) + "&limit=2", Prev: "/starred?cursor=" + encodeCursor(0) + "&limit=2"}, "", false},
+		"past_last_page":  {"?offset=5&limit=2", 5, 5, 5, model.PageLinks{Prev: "/starred?cursor=" + encodeCursor(4) + "&limit=2"}, "", false},
+		"empty_list":      {"", 0, 0, 0, model.PageLinks{}, "", false},
+		"zero_limit":      {"?limit=0", 5, 0, 0, model.PageLinks{}, "", true},
+		"negative_offset": {"?offset=-1", 5, 0, 0, model.PageLinks{}, "", true},
+		"invalid_cursor":  {"?cursor=abc", 5, 0, 0, model.PageLinks{}, "", true},
+		"cursor_overflow": {"?cursor=" + encodeCursor(math.MaxInt64), 5, 0, 0, model.PageLinks{}, "", true},
+		"cursor_too_big":  {"?cursor=" + encodeCursor(math.MaxInt32+1), 5, 0, 0, model.PageLinks{}, "", true},
+		"cursor_offset":   {"?cursor=" + encodeCursor(2) + "&offset=1", 5, 0, 0, model.PageLinks{}, "", true},
+	}
+	for testName, tc := range tt {
+		r := httptest.NewRequest("GET", "/starred"+tc.query, nil)
+		rr := httptest.NewRecorder()
+
+		page, err := newPageRequest(r)
+		if err != nil || tc.expectError {
+			if (err != nil) != tc.expectError {
+				t.Errorf("\nTest %s\nGot error %v, want error %v", testName, err, tc.expectError)
+			}
+			continue
+		}
+		start, end, links := paginate(c, rr, r, page, tc.total)
+
+		if start != tc.start || end != tc.end || !reflect.DeepEqual(links, tc.links) || (tc.header != "" && rr.Header().Get("Link") != tc.header) {
+			t.Errorf("\nTest %s\nGot [%d:%d], links %+v and header %s\nWant [%d:%d], links %+v and header %s",
+				testName, start, end, links, rr.Header().Get("Link"), tc.start, tc.end, tc.links, tc.header)
+		}
+	}
+}
+
+func TestGetAllStarredReposInvalidPage(t *testing.T) {
+	c := newTestConfig(t)
+
+	response := executeHandlerTest(c, GetAllStarredRepos, "GET", "/?limit=-1", "", map[string]string{"user": "joaopmgd"})
+
+	want := `{"error":"Param limit must be a number between 1 and 100"}`
+	if response.Code != http.StatusBadRequest || response.Body.String() != want {
+		t.Errorf("Got Status %v and Body %s\nWant Status %v and Body %s", response.Code, response.Body.String(), http.StatusBadRequest, want)
+	}
+}
@@ -2,54 +2,117 @@ package handler
 
 import (
 	"encoding/json"
+	"math"
 	"net/http"
-	"strconv"
-	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/joaopmgd/github-tag-api/app/model"
 	"github.com/joaopmgd/github-tag-api/config"
 	"github.com/joaopmgd/github-tag-api/database"
+	"github.com/joaopmgd/github-tag-api/metrics"
 )
 
 // GetAllStarredRepos will recover all the repos starred by an user
 func GetAllStarredRepos(config *config.Config, w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 
+	// Validate tag filter
+	filter, err := newTagFilter(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Validate sorting
+	repoSort, err := newRepoSort(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Validate pagination
+	page, err := newPageRequest(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// Validate URL
 	URL, err := config.GetStarredReposURL(vars)
 	if err != nil {
 		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	userStarredRepos, err := getUserStarredReposOr404(URL)
+	userStarredRepos, err := getUserStarredReposOr404(config, vars["user"], URL)
 	if err != nil {
 		config.Log.UnableToRequest(err.Error())
 		respondError(w, http.StatusNotFound, "User not found")
 		return
 	}
 	// Recover data from database
-	tags := config.DB.GetAllRepoTagsMap(vars["user"])
-	respondJSON(w, http.StatusOK, paginate(config, r, createMessageStarredReposSelectedTag(userStarredRepos, tags, r.FormValue("tag"))))
+	repoTags := config.DB.GetAllRepoTags(vars["user"])
+	tags, origins := repoTagsMaps(repoTags)
+	starredRepos := addTagSources(createMessageStarredReposSelectedTag(userStarredRepos, tags, filter), origins)
+	sortStarredRepos(starredRepos, repoSort, lastTaggedAt(repoTags))
+	start, end, links := paginate(config, w, r, page, len(starredRepos))
+	respondJSON(w, http.StatusOK, model.StarredRepoTagsResponse{
+		StarredRepos:         starredRepos[start:end],
+		PageNumber:           page.number(),
+		PageSize:             page.Limit,
+		PropertiesTotalCount: len(starredRepos),
+		PageLinks:            links,
+	})
 }
 
-// getUserStarredReposOr404 gets all user starred repos, or respond the 404 error otherwise
-func getUserStarredReposOr404(URL string) ([]model.StarredRepoRequest, error) {
-	var userStarredRepos []model.StarredRepoRequest
-	if err := requestData(&userStarredRepos, URL); err != nil {
-		return nil, err
+// getUserStarredReposOr404 gets all user starred repos from the mirror or from every Github page, or respond the 404 error otherwise
+func getUserStarredReposOr404(config *config.Config, user, URL string) ([]model.StarredRepoRequest, error) {
+	if mirrorEnabled(config) {
+		if userStarredRepos, found := readMirror(config, user); found {
+			return userStarredRepos, nil
+		}
 	}
-	return userStarredRepos, nil
+	return syncStarredRepos(config, user, URL)
 }
 
-func requestData(target interface{}, URL string) error {
-	var myClient = &http.Client{Timeout: 10 * time.Second}
-	r, err := myClient.Get(URL)
-	if err != nil {
-		return err
+// getStarredRepoOr404 finds the repo in the path among the starred ones, or respond the 404 error otherwise.
+// A name shared by many starred repos responds 409 with their full names.
+func getStarredRepoOr404(config *config.Config, w http.ResponseWriter, vars map[string]string, userStarredRepos []model.StarredRepoRequest) (model.StarredRepoRequest, bool) {
+	repo, candidates := findStarredRepo(userStarredRepos, vars)
+	if len(candidates) == 1 {
+		return repo, true
+	}
+	if len(candidates) == 0 {
+		config.Log.RepoNotFound(repoPath(vars))
+		respondError(w, http.StatusNotFound, "Repository not found "+repoPath(vars))
+		return model.StarredRepoRequest{}, false
+	}
+	names := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		names[i] = candidate.FullName
+	}
+	respondJSON(w, http.StatusConflict, model.AmbiguousRepoError{
+		Error:      "Repository name " + repoPath(vars) + " matches many starred repos, use the id or owner/name",
+		Candidates: names,
+	})
+	return model.StarredRepoRequest{}, false
+}
+
+// repoPath is the repo as it was requested, its id or name or owner/name
+func repoPath(vars map[string]string) string {
+	if vars["owner"] != "" {
+		return vars["owner"] + "/" + vars["name"]
+	}
+	return vars["repo"]
+}
+
+// InvalidateStarredReposCache removes the cached starred repos of an user, so the next request fetches them from Github
+func InvalidateStarredReposCache(config *config.Config, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if !config.Github.InvalidateStarredRepos(vars["user"]) {
+		respondError(w, http.StatusNotFound, "There is no cache for the user "+vars["user"])
+		return
 	}
-	defer r.Body.Close()
-	return json.NewDecoder(r.Body).Decode(target)
+	respondJSON(w, http.StatusOK, model.ResponseOK{Message: "Cache invalidated"})
 }
 
 // PostTagStarredRepo post a new tag for a repo
@@ -73,23 +136,16 @@ func PostTagStarredRepo(config *config.Config, w http.ResponseWriter, r *http.Re
 	}
 
 	// Validate request to github
-	userStarredRepos, err := getUserStarredReposOr404(URL)
+	userStarredRepos, err := getUserStarredReposOr404(config, vars["user"], URL)
 	if err != nil {
 		config.Log.UnableToRequest(err.Error())
 		respondError(w, http.StatusNotFound, "User not found")
 		return
 	}
 
-	// Validate request if id exists
-	var repo model.StarredRepoRequest
-	for _, starred := range userStarredRepos {
-		if vars["repo"] == strconv.FormatInt(starred.ID, 10) {
-			repo = starred
-		}
-	}
-	if repo.ID == 0 {
-		config.Log.RepoNotFound(vars["repo"])
-		respondError(w, http.StatusNotFound, "Repository not found "+vars["repo"])
+	// Validate request if repo exists
+	repo, found := getStarredRepoOr404(config, w, vars, userStarredRepos)
+	if !found {
 		return
 	}
 
@@ -99,14 +155,15 @@ func PostTagStarredRepo(config *config.Config, w http.ResponseWriter, r *http.Re
 	// If tag already exists return bad request
 	for _, tag := range tags {
 		if tag.TagName == tagData.TagName {
-			config.Log.RepoAlreadyTagged(vars["repo"])
+			config.Log.RepoAlreadyTagged(repo.FullName, tagData.TagName)
 			respondError(w, http.StatusBadRequest, "Repository already has the tag : "+tagData.TagName)
 			return
 		}
 	}
 	// Add to database
 	config.DB.InsertRepoTagsValue(database.RepoTag{UserID: vars["user"], RepoID: repo.ID, TagName: tagData.TagName})
-	config.DB.InsertLanguageTagsValue(database.LanguageTag{Language: repo.Language, TagName: tagData.TagName})
+	config.DB.InsertLanguageTagsValue(database.LanguageTag{UserID: vars["user"], RepoID: repo.ID, Language: repo.Language, TagName: tagData.TagName})
+	metrics.AddTags(tagSourceUser, 1)
 	respondJSON(w, http.StatusOK, model.ResponseOK{Message: "Tag added"})
 }
 
@@ -114,34 +171,52 @@ func PostTagStarredRepo(config *config.Config, w http.ResponseWriter, r *http.Re
 func GetARepoRecommendation(config *config.Config, w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 
+	// Validate recommendation params
+	limit, err := intParam(r, "limit", 10, 1, 100)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	minCount, err := intParam(r, "min_count", 1, 1, math.MaxInt32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// Validate URL
 	URL, err := config.GetStarredReposURL(vars)
 	if err != nil {
 		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	userStarredRepos, err := getUserStarredReposOr404(URL)
+	userStarredRepos, err := getUserStarredReposOr404(config, vars["user"], URL)
 	if err != nil {
 		config.Log.UnableToRequest(err.Error())
 		respondError(w, http.StatusNotFound, "User not found")
 		return
 	}
 
-	// Validate request if id exists
-	var repo model.StarredRepoRequest
-	for _, starred := range userStarredRepos {
-		if vars["repo"] == strconv.FormatInt(starred.ID, 10) {
-			repo = starred
+	// Validate request if repo exists
+	repo, found := getStarredRepoOr404(config, w, vars, userStarredRepos)
+	if !found {
+		return
+	}
+	// Recover data from database, the counts leave out the tags the repo already has as the suggestions do
+	repoTags := repoTagNames(config, vars["user"], repo.ID)
+	languageCounts := config.DB.GetRecommendationTagByLanguage(repo.Language, limit+len(repoTags), minCount)
+	counts := []model.RecommendedTag{}
+	for _, count := range languageCounts {
+		if !repoHasTag(repoTags, count.TagName, "") && len(counts) < limit {
+			counts = append(counts, model.RecommendedTag{Tag: count.TagName, Repos: count.Repos, Users: count.Users})
 		}
 	}
-	if repo.ID == 0 {
-		config.Log.RepoNotFound(vars["repo"])
-		respondError(w, http.StatusNotFound, "Repository not found "+vars["repo"])
-		return
+	suggested := recommendTags(config, vars["user"], repo, repoTags, userStarredRepos, languageCounts, limit, minCount)
+	metrics.ObserveRecommendation(suggested)
+	var tags []string
+	for _, suggestion := range suggested {
+		tags = append(tags, suggestion.Tag)
 	}
-	// Recover data from database
-	tags := config.DB.GetRecommendationTagByLanguage(repo.Language)
-	respondJSON(w, http.StatusOK, model.RecommendedTags{Recommended: addLanguage(repo.Language, tags)})
+	respondJSON(w, http.StatusOK, model.RecommendedTags{Recommended: addLanguage(repo.Language, tags), Counts: counts, Suggestions: suggested})
 }
 
 // HealthStatus checks github and database connectivity
@@ -155,17 +230,23 @@ func HealthStatus(config *config.Config, w http.ResponseWriter, r *http.Request)
 		databaseStatus = model.DatabaseStatus{Status: "down"}
 	}
 	URL := config.GetHealthStatusURL()
-	githubHealth, err := getHealthStatusOr404(URL)
+	githubHealth, err := getHealthStatusOr404(config, URL)
 	if err != nil || githubHealth.Status.Indicator != "none" {
 		overallStatus = "down"
 	}
-	respondJSON(w, http.StatusOK, model.AppHealthStatus{Status: overallStatus, Database: databaseStatus, GithubStatus: githubHealth.Status})
+	respondJSON(w, http.StatusOK, model.AppHealthStatus{
+		Status:          overallStatus,
+		Database:        databaseStatus,
+		GithubStatus:    githubHealth.Status,
+		GithubRateLimit: config.Github.RateLimit(),
+		Mirrors:         mirrorStatus(config),
+	})
 }
 
 // getHealthStatusOr404 gets the health status from github
-func getHealthStatusOr404(URL string) (model.GithubHealthStatus, error) {
+func getHealthStatusOr404(config *config.Config, URL string) (model.GithubHealthStatus, error) {
 	var health model.GithubHealthStatus
-	if err := requestData(&health, URL); err != nil {
+	if _, err := config.Github.GetPublicJSON(config.Context(), URL, &health); err != nil {
 		return model.GithubHealthStatus{}, err
 	}
 	return health, nil
@@ -192,26 +273,24 @@ func DeleteTagStarredRepo(config *config.Config, w http.ResponseWriter, r *http.
 	}
 
 	// Validate request to github
-	userStarredRepos, err := getUserStarredReposOr404(URL)
+	userStarredRepos, err := getUserStarredReposOr404(config, vars["user"], URL)
 	if err != nil {
 		config.Log.UnableToRequest(err.Error())
 		respondError(w, http.StatusNotFound, "User not found")
 		return
 	}
 
-	// Validate request if id exists
-	var repo model.StarredRepoRequest
-	for _, starred := range userStarredRepos {
-		if vars["repo"] == strconv.FormatInt(starred.ID, 10) {
-			repo = starred
-		}
-	}
-	if repo.ID == 0 {
-		config.Log.RepoNotFound(vars["repo"])
-		respondError(w, http.StatusNotFound, "Repository not found "+vars["repo"])
+	// Validate request if repo exists
+	repo, found := getStarredRepoOr404(config, w, vars, userStarredRepos)
+	if !found {
 		return
 	}
 
-	config.DB.DeleteRepoTagsValue(database.RepoTag{UserID: vars["user"], RepoID: repo.ID, TagName: tagData.TagName})
+	deleted := config.DB.DeleteRepoTagsValue(database.RepoTag{UserID: vars["user"], RepoID: repo.ID, TagName: tagData.TagName})
+	if deleted == 0 {
+		respondError(w, http.StatusNotFound, "Repository does not have the tag : "+tagData.TagName)
+		return
+	}
+	metrics.RemoveTags(tagSourceUser, deleted)
 	respondJSON(w, http.StatusOK, model.ResponseOK{Message: "Tag Deleted"})
 }
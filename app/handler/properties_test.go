@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/joaopmgd/github-tag-api/config"
+	"github.com/joaopmgd/github-tag-api/database"
+	"github.com/joaopmgd/github-tag-api/github"
+)
+
+var testStarredRepos = []model.StarredRepoRequest{
+	{ID: 10866521, FullName: "gorilla/mux", Name: "mux", Description: "A powerful HTTP router", URL: "https://api.github.com/repos/gorilla/mux", Language: "Go", Topics: []string{"go", "router"}, Stars: 19000},
+	{ID: 724712, FullName: "rust-lang/rust", Name: "rust", Description: "Empowering everyone to build reliable software", URL: "https://api.github.com/repos/rust-lang/rust", Language: "Rust", Stars: 90000},
+}
+
+// newTestConfig creates a config backed by the in-memory store and a fake Github server
+func newTestConfig(t *testing.T) *config.Config {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/joaopmgd/starred":
+			json.NewEncoder(w).Encode(testStarredRepos)
+		case "/status.json":
+			json.NewEncoder(w).Encode(model.GithubHealthStatus{Status: model.GithubStatus{Indicator: "none", Description: "All Systems Operational"}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(model.RequestError{Message: "Not Found"})
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	log := config.NewLogger()
+	log.Out = ioutil.Discard
+	return &config.Config{
+		Endpoints: &config.Endpoint{
+			GithubURL:          server.URL,
+			GithubUserStarred:  "/users/{{ .user }}/starred",
+			GithubHealthStatus: server.URL + "/status.json",
+		},
+		Log:    log,
+		DB:     database.NewMemory(),
+		Github: github.NewClient(nil, 0, 0),
+	}
+}
+
+func executeHandlerTest(c *config.Config, handler func(*config.Config, http.ResponseWriter, *http.Request), method, target, body string, vars map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req = mux.SetURLVars(req, vars)
+	rr := httptest.NewRecorder()
+	handler(c, rr, req)
+	return rr
+}
+
+func TestTagLifecycle(t *testing.T) {
+	c := newTestConfig(t)
+	repo := map[string]string{"user": "joaopmgd", "repo": "10866521"}
+	steps := []struct {
+		name           string
+		handler        func(*config.Config, http.ResponseWriter, *http.Request)
+		method         string
+		body           string
+		vars           map[string]string
+		responseStatus int
+		responseBody   string
+	}{
+		{"add_tag", PostTagStarredRepo, "POST", `{"tag": "router"}`, repo, http.StatusOK, `{"Message":"Tag added"}`},
+		{"repeated_tag", PostTagStarredRepo, "POST", `{"tag": "router"}`, repo, http.StatusBadRequest, `{"error":"Repository already has the tag : router"}`},
+		{"user_not_found", PostTagStarredRepo, "POST", `{"tag": "router"}`, map[string]string{"user": "nobody", "repo": "10866521"}, http.StatusNotFound, `{"error":"User not found"}`},
+		{"repo_not_found", PostTagStarredRepo, "POST", `{"tag": "router"}`, map[string]string{"user": "joaopmgd", "repo": "1"}, http.StatusNotFound, `{"error":"Repository not found 1"}`},
+		{"list_tagged", GetAllStarredRepos, "GET", "", repo, http.StatusOK, `{"starred_repos":[{"id":10866521,"full_name":"gorilla/mux","name":"mux","description":"A powerful HTTP router","url":"https://api.github.com/repos/gorilla/mux","language":"Go","tags":["router"],"topics":["go","router"],"stargazers_count":19000,"starred_at":"0001-01-01T00:00:00Z"},{"id":724712,"full_name":"rust-lang/rust","name":"rust","description":"Empowering everyone to build reliable software","url":"https://api.github.com/repos/rust-lang/rust","language":"Rust","tags":null,"topics":null,"stargazers_count":90000,"starred_at":"0001-01-01T00:00:00Z"}],"page_number":0,"page_size":10,"properties_total_count":2}`},
+		{"recommendation", GetARepoRecommendation, "GET", "", repo, http.StatusOK, `{"recommended":["Go"],"counts":[],"suggestions":[]}`},
+		{"delete_tag", DeleteTagStarredRepo, "DELETE", `{"tag": "router"}`, repo, http.StatusOK, `{"Message":"Tag Deleted"}`},
+		{"delete_missing_tag", DeleteTagStarredRepo, "DELETE", `{"tag": "router"}`, repo, http.StatusNotFound, `{"error":"Repository does not have the tag : router"}`},
+		{"add_tag_again", PostTagStarredRepo, "POST", `{"tag": "router"}`, repo, http.StatusOK, `{"Message":"Tag added"}`},
+		{"add_tag_by_full_name", PostTagStarredRepo, "POST", `{"tag": "http"}`, map[string]string{"user": "joaopmgd", "owner": "Gorilla", "name": "mux"}, http.StatusOK, `{"Message":"Tag added"}`},
+		{"delete_tag_by_name", DeleteTagStarredRepo, "DELETE", `{"tag": "http"}`, map[string]string{"user": "joaopmgd", "repo": "mux"}, http.StatusOK, `{"Message":"Tag Deleted"}`},
+		{"full_name_not_found", PostTagStarredRepo, "POST", `{"tag": "http"}`, map[string]string{"user": "joaopmgd", "owner": "other", "name": "mux"}, http.StatusNotFound, `{"error":"Repository not found other/mux"}`},
+	}
+	for _, step := range steps {
+
+		response := executeHandlerTest(c, step.handler, step.method, "/", step.body, step.vars)
+
+		if response.Code != step.responseStatus || strings.TrimSpace(response.Body.String()) != step.responseBody {
+			t.Errorf("\nStep %s\nGot Status %v and Body %s\nWant Status %v and Body %s",
+				step.name, response.Code, response.Body.String(), step.responseStatus, step.responseBody)
+		}
+	}
+}
+
+func TestHealthStatus(t *testing.T) {
+	c := newTestConfig(t)
+
+	response := executeHandlerTest(c, HealthStatus, "GET", "/", "", nil)
+
+	want := `{"status":"up","github":{"indicator":"none","description":"All Systems Operational"},"github_rate_limit":{"limit":0,"remaining":0,"reset":"0001-01-01T00:00:00Z","tokens":0},"database":{"status":"up"}}`
+	if response.Code != http.StatusOK || response.Body.String() != want {
+		t.Errorf("\nGot Status %v and Body %s\nWant Status %v and Body %s", response.Code, response.Body.String(), http.StatusOK, want)
+	}
+}
+
+func TestGetARepoRecommendationRanking(t *testing.T) {
+	c := newTestConfig(t)
+	for _, languageTag := range []database.LanguageTag{
+		{UserID: "a", RepoID: 1, Language: "Go", TagName: "cli"},
+		{UserID: "a", RepoID: 2, Language: "Go", TagName: "router"},
+		{UserID: "b", RepoID: 2, Language: "Go", TagName: "router"},
+		{UserID: "b", RepoID: 3, Language: "Go", TagName: "router"},
+		{UserID: "a", RepoID: 4, Language: "Go", TagName: "web"},
+		{UserID: "b", RepoID: 4, Language: "Go", TagName: "web"},
+		{UserID: "a", RepoID: 5, Language: "Rust", TagName: "cli"},
+	} {
+		c.DB.InsertLanguageTagsValue(languageTag)
+	}
+	repo := map[string]string{"user": "joaopmgd", "repo": "10866521"}
+	tt := map[string]struct {
+		query          string
+		responseStatus int
+		responseBody   string
+	}{
+		"ranked_by_usage": {"", http.StatusOK, `{"recommended":["router","web","cli","Go"],"counts":[{"tag":"router","repos":2,"users":2},{"tag":"web","repos":1,"users":2},{"tag":"cli","repos":1,"users":1}],"suggestions":[` +
+			`{"tag":"router","score":1,"reasons":[{"source":"language","detail":"Used in 2 Go repos","count":2}]},` +
+			`{"tag":"web","score":0.5,"reasons":[{"source":"language","detail":"Used in 1 Go repo","count":1}]},` +
+			`{"tag":"cli","score":0.5,"reasons":[{"source":"language","detail":"Used in 1 Go repo","count":1}]}]}`},
+		"limit": {"?limit=1", http.StatusOK, `{"recommended":["router","Go"],"counts":[{"tag":"router","repos":2,"users":2}],"suggestions":[` +
+			`{"tag":"router","score":1,"reasons":[{"source":"language","detail":"Used in 2 Go repos","count":2}]}]}`},
+		"min_count":     {"?min_count=3", http.StatusOK, `{"recommended":["Go"],"counts":[],"suggestions":[]}`},
+		"invalid_limit": {"?limit=0", http.StatusBadRequest, `{"error":"Param limit must be a number between 1 and 100"}`},
+	}
+	for testName, tc := range tt {
+
+		response := executeHandlerTest(c, GetARepoRecommendation, "GET", "/"+tc.query, "", repo)
+
+		if response.Code != tc.responseStatus || response.Body.String() != tc.responseBody {
+			t.Errorf("\nTest %s\nGot Status %v and Body %s\nWant Status %v and Body %s",
+				testName, response.Code, response.Body.String(), tc.responseStatus, tc.responseBody)
+		}
+	}
+}
+
+func TestGetARepoRecommendationSkipsRepoTags(t *testing.T) {
+	c := newTestConfig(t)
+	c.DB.InsertLanguageTagsValue(database.LanguageTag{UserID: "a", RepoID: 1, Language: "Go", TagName: "router"})
+	c.DB.InsertLanguageTagsValue(database.LanguageTag{UserID: "a", RepoID: 2, Language: "Go", TagName: "web"})
+	c.DB.InsertRepoTagsValue(database.RepoTag{UserID: "joaopmgd", RepoID: 10866521, TagName: "router"})
+
+	response := executeHandlerTest(c, GetARepoRecommendation, "GET", "/", "", map[string]string{"user": "joaopmgd", "repo": "10866521"})
+
+	want := `{"recommended":["web","Go"],"counts":[{"tag":"web","repos":1,"users":1}],"suggestions":[` +
+		`{"tag":"web","score":1,"reasons":[{"source":"language","detail":"Used in 1 Go repo","count":1}]}]}`
+	if response.Code != http.StatusOK || response.Body.String() != want {
+		t.Errorf("\nGot Status %v and Body %s\nWant Status %v and Body %s", response.Code, response.Body.String(), http.StatusOK, want)
+	}
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joaopmgd/github-tag-api/auth"
+	"github.com/joaopmgd/github-tag-api/config"
+	"github.com/joaopmgd/github-tag-api/ratelimit"
+)
+
+// RateLimit takes a token from the bucket of the client, responding 429 when it is empty.
+// The X-RateLimit headers are sent on every limited route group.
+func RateLimit(config *config.Config, w http.ResponseWriter, r *http.Request) bool {
+	if config.RateLimiter == nil {
+		return true
+	}
+	now := time.Now()
+	group := rateLimitGroup(r)
+	client := rateLimitClient(config, r, now)
+	result, limited := config.RateLimiter.Allow(group, client, now)
+	if !limited {
+		return true
+	}
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(result.Reset.Unix(), 10))
+	if result.Allowed {
+		return true
+	}
+	retryAfter := strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds())))
+	config.Log.RateLimited(group, client)
+	w.Header().Set("Retry-After", retryAfter)
+	respondError(w, http.StatusTooManyRequests, "Too many requests, retry after "+retryAfter+" seconds")
+	return false
+}
+
+// rateLimitGroup is the route group of the request, the device flow, the reads and the writes are limited apart
+func rateLimitGroup(r *http.Request) string {
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/auth/"):
+		return ratelimit.GroupAuth
+	case r.Method == "GET" || r.Method == "HEAD":
+		return ratelimit.GroupRead
+	}
+	return ratelimit.GroupWrite
+}
+
+// rateLimitClient identifies the client by its API key, or by its IP for every other request.
+// The key is checked first, so sending made up keys does not give a new bucket to each request.
+func rateLimitClient(config *config.Config, r *http.Request, now time.Time) string {
+	if key, valid := auth.ValidKey(config.DB, auth.Credential(r), now); valid {
+		return "key:" + key.Hash
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
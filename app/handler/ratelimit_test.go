@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/joaopmgd/github-tag-api/auth"
+	"github.com/joaopmgd/github-tag-api/database"
+	"github.com/joaopmgd/github-tag-api/ratelimit"
+)
+
+func TestRateLimit(t *testing.T) {
+	c := newTestConfig(t)
+	c.RateLimiter = ratelimit.New(map[string]ratelimit.Limit{
+		ratelimit.GroupWrite: {Requests: 1, Period: time.Minute},
+		ratelimit.GroupAuth:  {Requests: 1, Period: time.Minute},
+	})
+	c.DB.InsertAPIKey(&database.APIKey{UserID: "joaopmgd", Hash: auth.Hash("gta_key"), Scopes: auth.ScopeWrite})
+	steps := []struct {
+		name           string
+		method         string
+		target         string
+		remoteAddr     string
+		apiKey         string
+		responseStatus int
+		remaining      string
+		retryAfter     string
+	}{
+		{"first_write", "POST", "/repos/joaopmgd/starred/1", "10.0.0.1:1000", "", http.StatusOK, "0", ""},
+		{"second_write", "DELETE", "/repos/joaopmgd/starred/1", "10.0.0.1:2000", "", http.StatusTooManyRequests, "0", "60"},
+		{"read_not_limited", "GET", "/repos/joaopmgd/starred", "10.0.0.1:1000", "", http.StatusOK, "", ""},
+		{"auth_apart", "POST", "/auth/device", "10.0.0.1:1000", "", http.StatusOK, "0", ""},
+		{"other_ip", "POST", "/repos/joaopmgd/starred/1", "10.0.0.2:1000", "", http.StatusOK, "0", ""},
+		{"api_key", "POST", "/repos/joaopmgd/starred/1", "10.0.0.2:1000", "gta_key", http.StatusOK, "0", ""},
+		{"same_api_key", "POST", "/repos/joaopmgd/starred/1", "10.0.0.3:1000", "gta_key", http.StatusTooManyRequests, "0", "60"},
+		{"fake_api_key", "POST", "/repos/joaopmgd/starred/1", "10.0.0.4:1000", "gta_fake1", http.StatusOK, "0", ""},
+		{"rotated_fake_api_key", "POST", "/repos/joaopmgd/starred/1", "10.0.0.4:1000", "gta_fake2", http.StatusTooManyRequests, "0", "60"},
+		{"another_fake_api_key", "POST", "/repos/joaopmgd/starred/1", "10.0.0.4:1000", "gta_fake3", http.StatusTooManyRequests, "0", "60"},
+	}
+	for _, step := range steps {
+		req := httptest.NewRequest(step.method, step.target, nil)
+		req.RemoteAddr = step.remoteAddr
+		if step.apiKey != "" {
+			req.Header.Set("X-API-Key", step.apiKey)
+		}
+		rr := httptest.NewRecorder()
+
+		if RateLimit(c, rr, req) {
+			rr.WriteHeader(http.StatusOK)
+		}
+
+		if rr.Code != step.responseStatus || rr.Header().Get("X-RateLimit-Remaining") != step.remaining || rr.Header().Get("Retry-After") != step.retryAfter {
+			t.Errorf("\nTest %s\nGot Status %v, remaining '%s' and retry after '%s'\nWant Status %v, remaining '%s' and retry after '%s'",
+				step.name, rr.Code, rr.Header().Get("X-RateLimit-Remaining"), rr.Header().Get("Retry-After"), step.responseStatus, step.remaining, step.retryAfter)
+		}
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	c := newTestConfig(t)
+	rr := httptest.NewRecorder()
+
+	allowed := RateLimit(c, rr, httptest.NewRequest("POST", "/repos/joaopmgd/starred/1", nil))
+
+	if !allowed || rr.Header().Get("X-RateLimit-Limit") != "" {
+		t.Errorf("Got allowed %v and headers %v\nWant every request allowed without headers", allowed, rr.Header())
+	}
+}
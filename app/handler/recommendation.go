@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/joaopmgd/github-tag-api/app/recommend"
+	"github.com/joaopmgd/github-tag-api/config"
+	"github.com/joaopmgd/github-tag-api/database"
+)
+
+// Sources of the tag suggestions
+const (
+	sourceLanguage     = "language"
+	sourceCoOccurrence = "co_occurrence"
+	sourceOtherUsers   = "other_users"
+	sourceContent      = "content"
+)
+
+// sourceWeights is how much each source adds to the score of a suggestion
+var sourceWeights = map[string]float64{
+	sourceOtherUsers:   3,
+	sourceCoOccurrence: 2,
+	sourceContent:      2,
+	sourceLanguage:     1,
+}
+
+// contentNeighbours is how many similar repos are used for the content suggestions
+const contentNeighbours = 5
+
+// candidate is a tag suggested by a source, the value is divided by the biggest one of the source
+type candidate struct {
+	Tag    string
+	Value  float64
+	Count  int
+	Detail string
+}
+
+// recommendTags suggests tags for a repo of an user, from the tags other users applied to the same repo,
+// the tags that are applied together with the ones the repo already has, the tags of the user repos with
+// similar name and description and the tags used with its language, the tags the repo already has are never suggested
+func recommendTags(config *config.Config, user string, repo model.StarredRepoRequest, repoTags []string, userStarredRepos []model.StarredRepoRequest, languageCounts []database.TagCount, limit, minCount int) []model.TagSuggestion {
+	suggested := newSuggestions(repoTags, minCount)
+	// The tags the repo has are dropped after the queries, so each one asks for that many more
+	fetch := limit + len(repoTags)
+
+	suggested.add(sourceOtherUsers, countCandidates(config.DB.GetRepoTagsByOtherUsers(user, repo.ID, fetch),
+		func(count database.TagCount) (int, string) {
+			return count.Users, "Applied to this repo by " + plural(count.Users, "other user")
+		}))
+	for _, tag := range repoTags {
+		baseTag := tag
+		suggested.add(sourceCoOccurrence, countCandidates(config.DB.GetCoOccurringTags(baseTag, fetch),
+			func(count database.TagCount) (int, string) {
+				return count.Repos, "Tagged together with " + baseTag + " in " + plural(count.Repos, "repo")
+			}))
+	}
+	suggested.add(sourceContent, contentCandidates(repo, userStarredRepos, config.DB.GetAllRepoTagsMap(user)))
+	suggested.add(sourceLanguage, countCandidates(languageCounts,
+		func(count database.TagCount) (int, string) {
+			return count.Repos, "Used in " + plural(count.Repos, repo.Language+" repo")
+		}))
+	return suggested.ranked(limit)
+}
+
+// repoTagNames lists the tags the user applied to the repo
+func repoTagNames(config *config.Config, user string, repoID int64) []string {
+	var repoTags []string
+	for _, repoTag := range config.DB.GetAllRepoTagsByRepoID(user, repoID) {
+		repoTags = append(repoTags, repoTag.TagName)
+	}
+	return repoTags
+}
+
+// countCandidates turns the counts of a source into candidates, reason picks the count and explains it
+func countCandidates(counts []database.TagCount, reason func(database.TagCount) (int, string)) []candidate {
+	var candidates []candidate
+	for _, count := range counts {
+		value, detail := reason(count)
+		candidates = append(candidates, candidate{Tag: count.TagName, Value: float64(value), Count: value, Detail: detail})
+	}
+	return candidates
+}
+
+// contentCandidates suggests the tags of the user repos whose name and description are similar to the repo ones
+func contentCandidates(repo model.StarredRepoRequest, userStarredRepos []model.StarredRepoRequest, tags map[int64][]string) []candidate {
+	var documents []recommend.Document
+	names := make(map[int64]string)
+	for _, starred := range userStarredRepos {
+		names[starred.ID] = starred.Name
+		documents = append(documents, recommend.Document{ID: starred.ID, Text: starred.Name + " " + starred.Description, Tags: tags[starred.ID]})
+	}
+	index := recommend.NewIndex(documents)
+	var candidates []candidate
+	for _, suggestion := range index.SuggestTags(repo.Name+" "+repo.Description, contentNeighbours, repo.ID) {
+		var similar []string
+		for _, neighbour := range suggestion.Neighbours {
+			similar = append(similar, names[neighbour.ID])
+		}
+		candidates = append(candidates, candidate{
+			Tag:    suggestion.Tag,
+			Value:  suggestion.Score,
+			Count:  len(suggestion.Neighbours),
+			Detail: "Similar to " + strings.Join(similar, ", "),
+		})
+	}
+	return candidates
+}
+
+// suggestions accumulates the score and the reasons of each suggested tag, in the order they were first suggested
+type suggestions struct {
+	order    []string
+	byTag    map[string]*model.TagSuggestion
+	skip     map[string]bool
+	minCount int
+}
+
+// newSuggestions creates the suggestions, skipping the tags that the repo already has and the ones below minCount
+func newSuggestions(skip []string, minCount int) *suggestions {
+	s := &suggestions{byTag: make(map[string]*model.TagSuggestion), skip: make(map[string]bool), minCount: minCount}
+	for _, tag := range skip {
+		s.skip[tag] = true
+	}
+	return s
+}
+
+// add scores the candidates of a source, each value is divided by the biggest one of the source and multiplied by its weight
+func (s *suggestions) add(source string, candidates []candidate) {
+	var biggest float64
+	for _, candidate := range candidates {
+		biggest = math.Max(biggest, candidate.Value)
+	}
+	for _, candidate := range candidates {
+		if s.skip[candidate.Tag] || candidate.Count < s.minCount || candidate.Value <= 0 {
+			continue
+		}
+		suggestion, found := s.byTag[candidate.Tag]
+		if !found {
+			suggestion = &model.TagSuggestion{Tag: candidate.Tag}
+			s.byTag[candidate.Tag] = suggestion
+			s.order = append(s.order, candidate.Tag)
+		}
+		suggestion.Score += sourceWeights[source] * candidate.Value / biggest
+		suggestion.Reasons = append(suggestion.Reasons, model.SuggestionReason{Source: source, Detail: candidate.Detail, Count: candidate.Count})
+	}
+}
+
+// ranked returns up to limit suggestions, the best scores first
+func (s *suggestions) ranked(limit int) []model.TagSuggestion {
+	ranked := []model.TagSuggestion{}
+	for _, tag := range s.order {
+		suggestion := *s.byTag[tag]
+		suggestion.Score = math.Round(suggestion.Score*1000) / 1000
+		ranked = append(ranked, suggestion)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Score > ranked[j].Score })
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
+
+// plural writes the count with the noun, adding an s when it is not one
+func plural(count int, noun string) string {
+	if count == 1 {
+		return "1 " + noun
+	}
+	return strconv.Itoa(count) + " " + noun + "s"
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/joaopmgd/github-tag-api/database"
+)
+
+func TestRecommendationReasons(t *testing.T) {
+	c := newTestConfig(t)
+	for _, repoTag := range []database.RepoTag{
+		{UserID: "joaopmgd", RepoID: 10866521, TagName: "grpc"},
+		{UserID: "a", RepoID: 1, TagName: "grpc"},
+		{UserID: "a", RepoID: 1, TagName: "protobuf"},
+		{UserID: "b", RepoID: 2, TagName: "grpc"},
+		{UserID: "b", RepoID: 2, TagName: "protobuf"},
+		{UserID: "b", RepoID: 2, TagName: "rpc"},
+		{UserID: "c", RepoID: 10866521, TagName: "router"},
+		{UserID: "c", RepoID: 10866521, TagName: "grpc"},
+	} {
+		c.DB.InsertRepoTagsValue(repoTag)
+	}
+	c.DB.InsertLanguageTagsValue(database.LanguageTag{UserID: "c", RepoID: 10866521, Language: "Go", TagName: "router"})
+
+	response := executeHandlerTest(c, GetARepoRecommendation, "GET", "/", "", map[string]string{"user": "joaopmgd", "repo": "10866521"})
+
+	var recommended model.RecommendedTags
+	json.NewDecoder(response.Body).Decode(&recommended)
+	want := []model.TagSuggestion{
+		{Tag: "router", Score: 5, Reasons: []model.SuggestionReason{
+			{Source: sourceOtherUsers, Detail: "Applied to this repo by 1 other user", Count: 1},
+			{Source: sourceCoOccurrence, Detail: "Tagged together with grpc in 1 repo", Count: 1},
+			{Source: sourceLanguage, Detail: "Used in 1 Go repo", Count: 1},
+		}},
+		{Tag: "protobuf", Score: 2, Reasons: []model.SuggestionReason{
+			{Source: sourceCoOccurrence, Detail: "Tagged together with grpc in 2 repos", Count: 2},
+		}},
+		{Tag: "rpc", Score: 1, Reasons: []model.SuggestionReason{
+			{Source: sourceCoOccurrence, Detail: "Tagged together with grpc in 1 repo", Count: 1},
+		}},
+	}
+	if response.Code != http.StatusOK || !reflect.DeepEqual(recommended.Suggestions, want) {
+		t.Errorf("\nGot Status %v and Suggestions %+v\nWant Status %v and Suggestions %+v",
+			response.Code, recommended.Suggestions, http.StatusOK, want)
+	}
+	if !reflect.DeepEqual(recommended.Recommended, []string{"router", "protobuf", "rpc", "Go"}) {
+		t.Errorf("Got recommended %v", recommended.Recommended)
+	}
+}
+
+func TestGetARepoRecommendationOverFetches(t *testing.T) {
+	c := newTestConfig(t)
+	for _, repoTag := range []database.RepoTag{
+		{UserID: "joaopmgd", RepoID: 10866521, TagName: "a"},
+		{UserID: "joaopmgd", RepoID: 10866521, TagName: "b"},
+		{UserID: "x", RepoID: 10866521, TagName: "a"},
+		{UserID: "x", RepoID: 10866521, TagName: "b"},
+		{UserID: "y", RepoID: 10866521, TagName: "a"},
+		{UserID: "y", RepoID: 10866521, TagName: "b"},
+		{UserID: "z", RepoID: 10866521, TagName: "c"},
+	} {
+		c.DB.InsertRepoTagsValue(repoTag)
+	}
+
+	response := executeHandlerTest(c, GetARepoRecommendation, "GET", "/?limit=1", "", map[string]string{"user": "joaopmgd", "repo": "10866521"})
+
+	var recommended model.RecommendedTags
+	json.NewDecoder(response.Body).Decode(&recommended)
+	if len(recommended.Suggestions) != 1 || recommended.Suggestions[0].Tag != "c" {
+		t.Errorf("Got suggestions %+v\nWant c, the best tag the repo does not have yet", recommended.Suggestions)
+	}
+}
+
+func TestContentCandidates(t *testing.T) {
+	repos := []model.StarredRepoRequest{
+		{ID: 1, Name: "mux", Description: "A powerful HTTP router and URL matcher"},
+		{ID: 2, Name: "chi", Description: "Lightweight, idiomatic and composable router for building Go HTTP services"},
+		{ID: 3, Name: "tokio", Description: "A runtime for writing reliable asynchronous applications"},
+		{ID: 4, Name: "httprouter", Description: "A high performance HTTP request router"},
+	}
+	tags := map[int64][]string{1: {"router"}, 2: {"router", "go"}, 3: {"async"}}
+
+	candidates := contentCandidates(repos[3], repos, tags)
+
+	if len(candidates) != 2 ||
+		candidates[0].Tag != "router" || candidates[0].Count != 2 || candidates[0].Detail != "Similar to mux, chi" ||
+		candidates[1].Tag != "go" || candidates[1].Count != 1 || candidates[1].Detail != "Similar to chi" {
+		t.Errorf("Got candidates %+v, want router similar to mux and chi, and go similar to chi", candidates)
+	}
+}
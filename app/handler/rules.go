@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/joaopmgd/github-tag-api/app/rules"
+	"github.com/joaopmgd/github-tag-api/config"
+	"github.com/joaopmgd/github-tag-api/database"
+)
+
+// GetAutoTagRules lists the auto tagging rules of an user
+func GetAutoTagRules(config *config.Config, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	autoTagRules := []model.AutoTagRule{}
+	for _, stored := range config.DB.GetAutoTagRules(vars["user"]) {
+		rule, err := toAutoTagRule(stored)
+		if err != nil {
+			config.Log.InvalidAutoTagRule(vars["user"], stored.ID, err.Error())
+		}
+		autoTagRules = append(autoTagRules, rule)
+	}
+	respondJSON(w, http.StatusOK, autoTagRules)
+}
+
+// GetAutoTagRule recovers a single rule of an user
+func GetAutoTagRule(config *config.Config, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	rule, found := getAutoTagRuleOr404(config, w, vars)
+	if !found {
+		return
+	}
+	respondJSON(w, http.StatusOK, rule)
+}
+
+// CreateAutoTagRule stores a new rule and creates its tags
+func CreateAutoTagRule(config *config.Config, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	rule, valid := decodeAutoTagRule(config, w, r)
+	if !valid {
+		return
+	}
+	stored := toStoredAutoTagRule(vars["user"], rule)
+	if err := config.DB.InsertAutoTagRule(&stored); err != nil {
+		config.Log.DatabaseError(err.Error())
+		respondError(w, http.StatusInternalServerError, "Could not create the rule")
+		return
+	}
+	rule.ID = stored.ID
+	applyUserAutoTags(config, vars)
+	respondJSON(w, http.StatusCreated, rule)
+}
+
+// UpdateAutoTagRule replaces a rule of an user and creates its new tags, the tags already created by it are kept
+func UpdateAutoTagRule(config *config.Config, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["rule"], 10, 32)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Rule not found "+vars["rule"])
+		return
+	}
+	rule, valid := decodeAutoTagRule(config, w, r)
+	if !valid {
+		return
+	}
+	rule.ID = uint(id)
+	updated, err := config.DB.UpdateAutoTagRule(toStoredAutoTagRule(vars["user"], rule))
+	if err != nil {
+		config.Log.DatabaseError(err.Error())
+		respondError(w, http.StatusInternalServerError, "Could not update the rule")
+		return
+	}
+	if !updated {
+		respondError(w, http.StatusNotFound, "Rule not found "+vars["rule"])
+		return
+	}
+	applyUserAutoTags(config, vars)
+	respondJSON(w, http.StatusOK, rule)
+}
+
+// DeleteAutoTagRule deletes a rule of an user, the tags already created by it are kept
+func DeleteAutoTagRule(config *config.Config, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["rule"], 10, 32)
+	if err != nil || !config.DB.DeleteAutoTagRule(vars["user"], uint(id)) {
+		respondError(w, http.StatusNotFound, "Rule not found "+vars["rule"])
+		return
+	}
+	respondJSON(w, http.StatusOK, model.ResponseOK{Message: "Rule deleted"})
+}
+
+// DryRunAutoTagRule lists the starred repos matched by a stored rule, without tagging them
+func DryRunAutoTagRule(config *config.Config, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	rule, found := getAutoTagRuleOr404(config, w, vars)
+	if !found {
+		return
+	}
+	dryRunAutoTagRule(config, w, vars, rule)
+}
+
+// DryRunNewAutoTagRule lists the starred repos matched by the rule in the body, without storing it
+func DryRunNewAutoTagRule(config *config.Config, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	rule, valid := decodeAutoTagRule(config, w, r)
+	if !valid {
+		return
+	}
+	dryRunAutoTagRule(config, w, vars, rule)
+}
+
+func dryRunAutoTagRule(config *config.Config, w http.ResponseWriter, vars map[string]string, rule model.AutoTagRule) {
+	// Validate URL
+	URL, err := config.GetStarredReposURL(vars)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userStarredRepos, err := getUserStarredReposOr404(config, vars["user"], URL)
+	if err != nil {
+		config.Log.UnableToRequest(err.Error())
+		respondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	tags := config.DB.GetAllRepoTagsMap(vars["user"])
+	matches := []model.RuleMatch{}
+	for _, repo := range userStarredRepos {
+		if rules.Matches(rule, repo) {
+			matches = append(matches, model.RuleMatch{
+				ID:            repo.ID,
+				Name:          repo.Name,
+				AlreadyTagged: repoHasTag(tags[repo.ID], rule.Tag, tagModeExact),
+			})
+		}
+	}
+	respondJSON(w, http.StatusOK, model.RuleDryRunResponse{Rule: rule, Matches: matches})
+}
+
+// getAutoTagRuleOr404 recovers the rule in the path, or respond the 404 error otherwise
+func getAutoTagRuleOr404(config *config.Config, w http.ResponseWriter, vars map[string]string) (model.AutoTagRule, bool) {
+	id, err := strconv.ParseUint(vars["rule"], 10, 32)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Rule not found "+vars["rule"])
+		return model.AutoTagRule{}, false
+	}
+	stored, found := config.DB.GetAutoTagRule(vars["user"], uint(id))
+	if !found {
+		respondError(w, http.StatusNotFound, "Rule not found "+vars["rule"])
+		return model.AutoTagRule{}, false
+	}
+	rule, err := toAutoTagRule(stored)
+	if err != nil {
+		config.Log.InvalidAutoTagRule(vars["user"], stored.ID, err.Error())
+		respondError(w, http.StatusInternalServerError, "Could not read the rule "+vars["rule"])
+		return model.AutoTagRule{}, false
+	}
+	return rule, true
+}
+
+// decodeAutoTagRule reads and validates the rule in the body, or respond the 400 error otherwise
+func decodeAutoTagRule(config *config.Config, w http.ResponseWriter, r *http.Request) (model.AutoTagRule, bool) {
+	var rule model.AutoTagRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		config.Log.CouldNotParseRequestBody(err.Error())
+		respondError(w, http.StatusBadRequest, "Body must be a JSON rule with a 'tag' and its 'conditions'")
+		return model.AutoTagRule{}, false
+	}
+	if err := rules.Validate(rule); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return model.AutoTagRule{}, false
+	}
+	return rule, true
+}
+
+func toStoredAutoTagRule(user string, rule model.AutoTagRule) database.AutoTagRule {
+	conditions, _ := json.Marshal(rule.Conditions)
+	stored := database.AutoTagRule{UserID: user, Name: rule.Name, TagName: rule.Tag, Conditions: string(conditions)}
+	stored.ID = rule.ID
+	return stored
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/joaopmgd/github-tag-api/config"
+)
+
+func TestAutoTagRuleLifecycle(t *testing.T) {
+	c := newTestConfig(t)
+	user := map[string]string{"user": "joaopmgd"}
+	rule := map[string]string{"user": "joaopmgd", "rule": "1"}
+	rustRule := `{"name":"rust libs","tag":"rust-lang","conditions":[{"field":"language","operator":"equals","value":"rust"},{"field":"description","operator":"contains","value":"reliable"}]}`
+	steps := []struct {
+		name           string
+		handler        func(*config.Config, http.ResponseWriter, *http.Request)
+		method         string
+		body           string
+		vars           map[string]string
+		responseStatus int
+		responseBody   string
+	}{
+		{"invalid_rule", CreateAutoTagRule, "POST", `{"tag":"rust","conditions":[{"field":"stars","operator":"equals","value":"1"}]}`, user, http.StatusBadRequest, `{"error":"Condition field must be one of: name, description, language, topic"}`},
+		{"dry_run_new", DryRunNewAutoTagRule, "POST", `{"tag":"router","conditions":[{"field":"topic","operator":"equals","value":"router"}]}`, user, http.StatusOK, `{"rule":{"id":0,"name":"","tag":"router","conditions":[{"field":"topic","operator":"equals","value":"router"}]},"matches":[{"id":10866521,"name":"mux","already_tagged":false}]}`},
+		{"create", CreateAutoTagRule, "POST", rustRule, user, http.StatusCreated, `{"id":1,` + rustRule[1:]},
+		{"list", GetAutoTagRules, "GET", "", user, http.StatusOK, `[{"id":1,` + rustRule[1:] + `]`},
+		{"dry_run", DryRunAutoTagRule, "POST", "", rule, http.StatusOK, `{"rule":{"id":1,` + rustRule[1:] + `,"matches":[{"id":724712,"name":"rust","already_tagged":true}]}`},
+		{"update", UpdateAutoTagRule, "PUT", `{"tag":"rust","conditions":[{"field":"language","operator":"equals","value":"Rust"}]}`, rule, http.StatusOK, `{"id":1,"name":"","tag":"rust","conditions":[{"field":"language","operator":"equals","value":"Rust"}]}`},
+		{"get", GetAutoTagRule, "GET", "", rule, http.StatusOK, `{"id":1,"name":"","tag":"rust","conditions":[{"field":"language","operator":"equals","value":"Rust"}]}`},
+		{"update_not_found", UpdateAutoTagRule, "PUT", rustRule, map[string]string{"user": "other", "rule": "1"}, http.StatusNotFound, `{"error":"Rule not found 1"}`},
+		{"delete", DeleteAutoTagRule, "DELETE", "", rule, http.StatusOK, `{"Message":"Rule deleted"}`},
+		{"deleted", GetAutoTagRule, "GET", "", rule, http.StatusNotFound, `{"error":"Rule not found 1"}`},
+	}
+	for _, step := range steps {
+
+		response := executeHandlerTest(c, step.handler, step.method, "/", step.body, step.vars)
+
+		if response.Code != step.responseStatus || strings.TrimSpace(response.Body.String()) != step.responseBody {
+			t.Errorf("\nStep %s\nGot Status %v and Body %s\nWant Status %v and Body %s",
+				step.name, response.Code, response.Body.String(), step.responseStatus, step.responseBody)
+		}
+	}
+}
+
+func TestAutoTagRulesApplied(t *testing.T) {
+	c := newTestConfig(t)
+	user := map[string]string{"user": "joaopmgd"}
+	executeHandlerTest(c, CreateAutoTagRule, "POST", "/", `{"tag":"http","conditions":[{"field":"description","operator":"matches","value":"(?i)http"}]}`, user)
+	if tags := c.DB.GetAllRepoTagsMap("joaopmgd"); !reflect.DeepEqual(tags, map[int64][]string{10866521: {"http"}}) {
+		t.Errorf("Got tags %v right after creating the rule, want mux tagged http", tags)
+	}
+
+	response := executeHandlerTest(c, GetAllStarredRepos, "GET", "/", "", user)
+
+	var starred model.StarredRepoTagsResponse
+	json.NewDecoder(response.Body).Decode(&starred)
+	mux := starred.StarredRepos[0]
+	if response.Code != http.StatusOK ||
+		!reflect.DeepEqual(mux.Tags, []string{"http"}) ||
+		!reflect.DeepEqual(mux.TagSources, map[string]string{"http": "rule"}) ||
+		!reflect.DeepEqual(mux.TagRules, map[string]uint{"http": 1}) ||
+		starred.StarredRepos[1].Tags != nil {
+		t.Errorf("Got status %v and repos %+v, want only mux tagged http by the rule 1", response.Code, starred.StarredRepos)
+	}
+}
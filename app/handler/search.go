@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/joaopmgd/github-tag-api/app/search"
+	"github.com/joaopmgd/github-tag-api/config"
+)
+
+// SearchStarredRepos searches the name, description, language and tags of the starred repos, ranked by relevance
+func SearchStarredRepos(config *config.Config, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	// Validate query
+	query := strings.TrimSpace(r.FormValue("q"))
+	if query == "" {
+		respondError(w, http.StatusBadRequest, "Param q must not be empty")
+		return
+	}
+
+	// Validate tag filter
+	filter, err := newTagFilter(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Validate pagination
+	page, err := newPageRequest(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Validate URL
+	URL, err := config.GetStarredReposURL(vars)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userStarredRepos, err := getUserStarredReposOr404(config, vars["user"], URL)
+	if err != nil {
+		config.Log.UnableToRequest(err.Error())
+		respondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	// Recover data from database
+	tags, origins := repoTagsMaps(config.DB.GetAllRepoTags(vars["user"]))
+	starredRepos := addTagSources(createMessageStarredReposSelectedTag(userStarredRepos, tags, filter), origins)
+	results := searchStarredRepos(starredRepos, query)
+
+	start, end, links := paginate(config, w, r, page, len(results))
+	respondJSON(w, http.StatusOK, model.StarredRepoSearchResponse{
+		Query:                query,
+		Results:              results[start:end],
+		PageNumber:           page.number(),
+		PageSize:             page.Limit,
+		PropertiesTotalCount: len(results),
+		PageLinks:            links,
+	})
+}
+
+// searchStarredRepos ranks the repos matching the query
+func searchStarredRepos(starredRepos []model.StarredRepoTags, query string) []model.StarredRepoSearchResult {
+	documents := make([]search.Document, len(starredRepos))
+	byID := make(map[int64]model.StarredRepoTags)
+	for i, repo := range starredRepos {
+		documents[i] = search.Document{ID: repo.ID, Name: repo.Name, Description: repo.Description, Language: repo.Language, Tags: repo.Tags}
+		byID[repo.ID] = repo
+	}
+	results := []model.StarredRepoSearchResult{}
+	for _, result := range search.Search(documents, query) {
+		results = append(results, model.StarredRepoSearchResult{
+			StarredRepoTags: byID[result.ID],
+			Score:           result.Score,
+			Highlights:      result.Highlights,
+		})
+	}
+	return results
+}
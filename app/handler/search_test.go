@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/joaopmgd/github-tag-api/database"
+)
+
+func TestSearchStarredRepos(t *testing.T) {
+	c := newTestConfig(t)
+	c.DB.InsertRepoTagsValue(database.RepoTag{UserID: "joaopmgd", RepoID: 724712, TagName: "compiler"})
+	user := map[string]string{"user": "joaopmgd"}
+	tt := map[string]struct {
+		query          string
+		responseStatus int
+		ids            []int64
+	}{
+		"description":   {"?q=that+http+router", http.StatusOK, []int64{10866521}},
+		"tag":           {"?q=compiler", http.StatusOK, []int64{724712}},
+		"both_ranked":   {"?q=rust+router+reliable", http.StatusOK, []int64{724712, 10866521}},
+		"tag_filter":    {"?q=rust+router&tag=compiler", http.StatusOK, []int64{724712}},
+		"no_results":    {"?q=python", http.StatusOK, []int64{}},
+		"empty_query":   {"?q=+", http.StatusBadRequest, []int64{}},
+		"invalid_limit": {"?q=go&limit=0", http.StatusBadRequest, []int64{}},
+	}
+	for testName, tc := range tt {
+
+		response := executeHandlerTest(c, SearchStarredRepos, "GET", "/"+tc.query, "", user)
+
+		var found model.StarredRepoSearchResponse
+		json.NewDecoder(response.Body).Decode(&found)
+		ids := []int64{}
+		for _, result := range found.Results {
+			ids = append(ids, result.ID)
+		}
+		if response.Code != tc.responseStatus || !reflect.DeepEqual(ids, tc.ids) {
+			t.Errorf("\nTest %s\nGot Status %v and repos %v\nWant Status %v and repos %v", testName, response.Code, ids, tc.responseStatus, tc.ids)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/joaopmgd/github-tag-api/database"
+)
+
+// starredRepoLess compares two repos by each sort option in ascending order, taggedAt has when each repo was last tagged
+var starredRepoLess = map[string]func(a, b model.StarredRepoTags, taggedAt map[int64]time.Time) bool{
+	"name": func(a, b model.StarredRepoTags, _ map[int64]time.Time) bool {
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	},
+	"language": func(a, b model.StarredRepoTags, _ map[int64]time.Time) bool {
+		return strings.ToLower(a.Language) < strings.ToLower(b.Language)
+	},
+	"tag_count": func(a, b model.StarredRepoTags, _ map[int64]time.Time) bool { return len(a.Tags) < len(b.Tags) },
+	"starred_at": func(a, b model.StarredRepoTags, _ map[int64]time.Time) bool {
+		return a.StarredAt.Before(b.StarredAt)
+	},
+	"tagged_at": func(a, b model.StarredRepoTags, taggedAt map[int64]time.Time) bool {
+		return taggedAt[a.ID].Before(taggedAt[b.ID])
+	},
+	"stars": func(a, b model.StarredRepoTags, _ map[int64]time.Time) bool { return a.Stars < b.Stars },
+}
+
+// repoSort is the sort requested for the starred repos, an empty By keeps the Github order
+type repoSort struct {
+	By    string
+	Order string
+}
+
+// newRepoSort reads the sort and order query params, the text options are ascending by default and the others descending
+func newRepoSort(r *http.Request) (repoSort, error) {
+	s := repoSort{By: r.FormValue("sort"), Order: r.FormValue("order")}
+	if s.By == "" {
+		if s.Order != "" {
+			return repoSort{}, errors.New("Order needs a sort param")
+		}
+		return s, nil
+	}
+	if _, found := starredRepoLess[s.By]; !found {
+		return repoSort{}, errors.New("Sort must be one of: name, language, tag_count, starred_at, tagged_at, stars")
+	}
+	if s.Order == "" {
+		s.Order = "desc"
+		if s.By == "name" || s.By == "language" {
+			s.Order = "asc"
+		}
+	}
+	if s.Order != "asc" && s.Order != "desc" {
+		return repoSort{}, errors.New("Order must be asc or desc")
+	}
+	return s, nil
+}
+
+// sortStarredRepos sorts the repos in place, the ties are ordered by ID so the pages do not change between calls
+func sortStarredRepos(starredRepos []model.StarredRepoTags, s repoSort, taggedAt map[int64]time.Time) {
+	less, found := starredRepoLess[s.By]
+	if !found {
+		return
+	}
+	sort.SliceStable(starredRepos, func(i, j int) bool {
+		if less(starredRepos[i], starredRepos[j], taggedAt) {
+			return s.Order == "asc"
+		}
+		if less(starredRepos[j], starredRepos[i], taggedAt) {
+			return s.Order == "desc"
+		}
+		return starredRepos[i].ID < starredRepos[j].ID
+	})
+}
+
+// lastTaggedAt has when each repo got its latest tag
+func lastTaggedAt(repoTags []database.RepoTag) map[int64]time.Time {
+	taggedAt := make(map[int64]time.Time)
+	for _, repoTag := range repoTags {
+		if repoTag.CreatedAt.After(taggedAt[repoTag.RepoID]) {
+			taggedAt[repoTag.RepoID] = repoTag.CreatedAt
+		}
+	}
+	return taggedAt
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/joaopmgd/github-tag-api/app/model"
+)
+
+func TestSortStarredRepos(t *testing.T) {
+	now := time.Now()
+	repos := []model.StarredRepoTags{
+		{ID: 3, Name: "mux", Language: "Go", Tags: []string{"router"}, Stars: 100, StarredAt: now.Add(-time.Hour)},
+		{ID: 1, Name: "Django", Language: "Python", Tags: []string{"web", "python"}, Stars: 200, StarredAt: now},
+		{ID: 2, Name: "gin", Language: "Go", Stars: 100, StarredAt: now.Add(-2 * time.Hour)},
+	}
+	taggedAt := map[int64]time.Time{3: now, 1: now.Add(-time.Minute)}
+	tt := map[string]struct {
+		query string
+		ids   []int64
+	}{
+		"github_order":    {"", []int64{3, 1, 2}},
+		"name":            {"sort=name", []int64{1, 2, 3}},
+		"name_desc":       {"sort=name&order=desc", []int64{3, 2, 1}},
+		"language_ties":   {"sort=language", []int64{2, 3, 1}},
+		"tag_count":       {"sort=tag_count", []int64{1, 3, 2}},
+		"starred_at":      {"sort=starred_at", []int64{1, 3, 2}},
+		"tagged_at":       {"sort=tagged_at", []int64{3, 1, 2}},
+		"stars_ties":      {"sort=stars", []int64{1, 2, 3}},
+		"stars_ascending": {"sort=stars&order=asc", []int64{2, 3, 1}},
+	}
+	for testName, tc := range tt {
+		sorted := append([]model.StarredRepoTags{}, repos...)
+		s, err := newRepoSort(httptest.NewRequest("GET", "/?"+tc.query, nil))
+
+		sortStarredRepos(sorted, s, taggedAt)
+
+		var ids []int64
+		for _, repo := range sorted {
+			ids = append(ids, repo.ID)
+		}
+		if err != nil || !reflect.DeepEqual(ids, tc.ids) {
+			t.Errorf("\nTest %s\nGot %v and error %v\nWant %v", testName, ids, err, tc.ids)
+		}
+	}
+}
+
+func TestNewRepoSort(t *testing.T) {
+	tt := map[string]struct {
+		query       string
+		repoSort    repoSort
+		expectError bool
+	}{
+		"no_params":      {"", repoSort{}, false},
+		"text_default":   {"sort=language", repoSort{By: "language", Order: "asc"}, false},
+		"number_default": {"sort=stars", repoSort{By: "stars", Order: "desc"}, false},
+		"invalid_sort":   {"sort=size", repoSort{}, true},
+		"invalid_order":  {"sort=name&order=up", repoSort{}, true},
+		"order_alone":    {"order=asc", repoSort{}, true},
+	}
+	for testName, tc := range tt {
+
+		s, err := newRepoSort(httptest.NewRequest("GET", "/?"+tc.query, nil))
+
+		if (err != nil) != tc.expectError || s != tc.repoSort {
+			t.Errorf("\nTest %s\nGot %+v and error %v\nWant %+v and error %v", testName, s, err, tc.repoSort, tc.expectError)
+		}
+	}
+}
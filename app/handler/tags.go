@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/joaopmgd/github-tag-api/config"
+)
+
+// tagUsageLess compares two tags by each sort option, in ascending order
+var tagUsageLess = map[string]func(a, b model.TagUsage) bool{
+	"name":       func(a, b model.TagUsage) bool { return a.Tag < b.Tag },
+	"count":      func(a, b model.TagUsage) bool { return a.RepoCount < b.RepoCount },
+	"first_used": func(a, b model.TagUsage) bool { return a.FirstUsedAt.Before(b.FirstUsedAt) },
+	"last_used":  func(a, b model.TagUsage) bool { return a.LastUsedAt.Before(b.LastUsedAt) },
+}
+
+// GetUserTags lists every tag used by an user with its repo count
+func GetUserTags(config *config.Config, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	// Validate sorting
+	sortBy := r.FormValue("sort")
+	if sortBy == "" {
+		sortBy = "count"
+	}
+	less, found := tagUsageLess[sortBy]
+	if !found {
+		respondError(w, http.StatusBadRequest, "Sort must be one of: name, count, first_used, last_used")
+		return
+	}
+	order := r.FormValue("order")
+	if order == "" {
+		order = "desc"
+		if sortBy == "name" {
+			order = "asc"
+		}
+	}
+	if order != "asc" && order != "desc" {
+		respondError(w, http.StatusBadRequest, "Order must be asc or desc")
+		return
+	}
+
+	// Validate pagination
+	page, err := newPageRequest(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Recover data from database
+	tags := []model.TagUsage{}
+	for _, usage := range config.DB.GetTagUsage(vars["user"]) {
+		tags = append(tags, model.TagUsage{
+			Tag:         usage.TagName,
+			RepoCount:   usage.RepoCount,
+			FirstUsedAt: usage.FirstUsed,
+			LastUsedAt:  usage.LastUsed,
+		})
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		if less(tags[i], tags[j]) {
+			return order == "asc"
+		}
+		if less(tags[j], tags[i]) {
+			return order == "desc"
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+
+	start, end, links := paginate(config, w, r, page, len(tags))
+	respondJSON(w, http.StatusOK, model.TagUsageResponse{
+		Tags:                 tags[start:end],
+		PageNumber:           page.number(),
+		PageSize:             page.Limit,
+		PropertiesTotalCount: len(tags),
+		PageLinks:            links,
+	})
+}
+
+// RenameUserTag renames a tag in every repo of an user, merging it if the new name is already used
+func RenameUserTag(config *config.Config, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	// Validate body
+	var tagData model.TagRequestUpdate
+	err := json.NewDecoder(r.Body).Decode(&tagData)
+	if err != nil || strings.TrimSpace(tagData.TagName) == "" {
+		config.Log.CouldNotParseRequestBody(errorMessage(err))
+		respondError(w, http.StatusBadRequest, "Body must have a JSON key named 'tag' and its value")
+		return
+	}
+	if tagData.TagName == vars["tag"] {
+		respondError(w, http.StatusBadRequest, "The new tag must be different from "+vars["tag"])
+		return
+	}
+	mergeUserTags(config, w, vars["user"], []string{vars["tag"]}, tagData.TagName, "Tag renamed")
+}
+
+// MergeUserTags folds several tags of an user into a single one
+func MergeUserTags(config *config.Config, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	// Validate body
+	var mergeData model.TagMergeRequest
+	err := json.NewDecoder(r.Body).Decode(&mergeData)
+	if err != nil || len(mergeData.Tags) == 0 || strings.TrimSpace(mergeData.Into) == "" {
+		config.Log.CouldNotParseRequestBody(errorMessage(err))
+		respondError(w, http.StatusBadRequest, "Body must have a JSON key named 'tags' with the tags to merge and 'into' with the resulting tag")
+		return
+	}
+	// The resulting tag may be listed with the merged ones, it is kept as it is
+	var from []string
+	for _, tag := range mergeData.Tags {
+		if tag != mergeData.Into {
+			from = append(from, tag)
+		}
+	}
+	if len(from) == 0 {
+		respondError(w, http.StatusBadRequest, "The tags to merge must be different from "+mergeData.Into)
+		return
+	}
+	mergeUserTags(config, w, vars["user"], from, mergeData.Into, "Tags merged")
+}
+
+func mergeUserTags(config *config.Config, w http.ResponseWriter, user string, from []string, to, message string) {
+	repos, err := config.DB.MergeTags(user, from, to)
+	if err != nil {
+		config.Log.DatabaseError(err.Error())
+		respondError(w, http.StatusInternalServerError, "Could not change the tags")
+		return
+	}
+	if repos == 0 {
+		respondError(w, http.StatusNotFound, "Tag not found : "+strings.Join(from, ", "))
+		return
+	}
+	respondJSON(w, http.StatusOK, model.TagChangeResponse{Message: message, Tag: to, Repos: repos})
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/joaopmgd/github-tag-api/config"
+	"github.com/joaopmgd/github-tag-api/database"
+)
+
+func TestGetUserTags(t *testing.T) {
+	c := newTestConfig(t)
+	for _, repoTag := range []database.RepoTag{
+		{UserID: "joaopmgd", RepoID: 1, TagName: "go"},
+		{UserID: "joaopmgd", RepoID: 2, TagName: "go"},
+		{UserID: "joaopmgd", RepoID: 2, TagName: "cli"},
+		{UserID: "joaopmgd", RepoID: 3, TagName: "rust"},
+		{UserID: "joaopmgd", RepoID: 3, TagName: "cli"},
+		{UserID: "joaopmgd", RepoID: 4, TagName: "cli"},
+		{UserID: "someone", RepoID: 1, TagName: "router"},
+	} {
+		c.DB.InsertRepoTagsValue(repoTag)
+	}
+	tt := map[string]struct {
+		query          string
+		responseStatus int
+		expected       string
+	}{
+		"default_by_count": {"", http.StatusOK, "[cli:3 go:2 rust:1]"},
+		"by_name":          {"?sort=name", http.StatusOK, "[cli:3 go:2 rust:1]"},
+		"by_name_desc":     {"?sort=name&order=desc", http.StatusOK, "[rust:1 go:2 cli:3]"},
+		"by_count_asc":     {"?sort=count&order=asc", http.StatusOK, "[rust:1 go:2 cli:3]"},
+		"paginated":        {"?offset=1&limit=2", http.StatusOK, "[rust:1]"},
+		"past_last_page":   {"?offset=5&limit=2", http.StatusOK, "[]"},
+		"invalid_sort":     {"?sort=size", http.StatusBadRequest, "[]"},
+		"invalid_order":    {"?order=up", http.StatusBadRequest, "[]"},
+	}
+	for testName, tc := range tt {
+
+		response := executeHandlerTest(c, GetUserTags, "GET", "/"+tc.query, "", map[string]string{"user": "joaopmgd"})
+
+		var tags model.TagUsageResponse
+		json.NewDecoder(response.Body).Decode(&tags)
+		got := []string{}
+		for _, tag := range tags.Tags {
+			got = append(got, fmt.Sprintf("%s:%d", tag.Tag, tag.RepoCount))
+		}
+		if response.Code != tc.responseStatus || fmt.Sprint(got) != tc.expected {
+			t.Errorf("\nTest %s\nGot Status %v and Tags %v\nWant Status %v and Tags %s",
+				testName, response.Code, got, tc.responseStatus, tc.expected)
+		}
+	}
+}
+
+func TestRenameAndMergeUserTags(t *testing.T) {
+	c := newTestConfig(t)
+	for _, repoTag := range []database.RepoTag{
+		{UserID: "joaopmgd", RepoID: 1, TagName: "k8s"},
+		{UserID: "joaopmgd", RepoID: 2, TagName: "k8s"},
+		{UserID: "joaopmgd", RepoID: 2, TagName: "kubernetes"},
+		{UserID: "joaopmgd", RepoID: 3, TagName: "kube"},
+		{UserID: "someone", RepoID: 1, TagName: "k8s"},
+	} {
+		c.DB.InsertRepoTagsValue(repoTag)
+		c.DB.InsertLanguageTagsValue(database.LanguageTag{UserID: repoTag.UserID, RepoID: repoTag.RepoID, Language: "Go", TagName: repoTag.TagName})
+	}
+	steps := []struct {
+		name           string
+		handler        func(*config.Config, http.ResponseWriter, *http.Request)
+		method         string
+		body           string
+		tag            string
+		responseStatus int
+		responseBody   string
+	}{
+		{"rename_not_found", RenameUserTag, "PATCH", `{"tag": "docker"}`, "moby", http.StatusNotFound, `{"error":"Tag not found : moby"}`},
+		{"rename_no_body", RenameUserTag, "PATCH", ``, "kube", http.StatusBadRequest, `{"error":"Body must have a JSON key named 'tag' and its value"}`},
+		{"rename", RenameUserTag, "PATCH", `{"tag": "k8s"}`, "kube", http.StatusOK, `{"Message":"Tag renamed","tag":"k8s","repos":1}`},
+		{"merge_no_into", MergeUserTags, "POST", `{"tags": ["k8s"]}`, "", http.StatusBadRequest, `{"error":"Body must have a JSON key named 'tags' with the tags to merge and 'into' with the resulting tag"}`},
+		{"rename_to_itself", RenameUserTag, "PATCH", `{"tag": "kube"}`, "kube", http.StatusBadRequest, `{"error":"The new tag must be different from kube"}`},
+		{"merge_into_itself", MergeUserTags, "POST", `{"tags": ["kubernetes"], "into": "kubernetes"}`, "", http.StatusBadRequest, `{"error":"The tags to merge must be different from kubernetes"}`},
+		{"merge_missing_tag", MergeUserTags, "POST", `{"tags": ["kubernetes", "moby"], "into": "kubernetes"}`, "", http.StatusNotFound, `{"error":"Tag not found : moby"}`},
+		{"merge", MergeUserTags, "POST", `{"tags": ["k8s", "kubernetes"], "into": "kubernetes"}`, "", http.StatusOK, `{"Message":"Tags merged","tag":"kubernetes","repos":3}`},
+	}
+	for _, step := range steps {
+
+		response := executeHandlerTest(c, step.handler, step.method, "/", step.body, map[string]string{"user": "joaopmgd", "tag": step.tag})
+
+		if response.Code != step.responseStatus || response.Body.String() != step.responseBody {
+			t.Errorf("\nStep %s\nGot Status %v and Body %s\nWant Status %v and Body %s",
+				step.name, response.Code, response.Body.String(), step.responseStatus, step.responseBody)
+		}
+	}
+
+	tags := c.DB.GetAllRepoTagsMap("joaopmgd")
+	if fmt.Sprint(tags) != "map[1:[kubernetes] 2:[kubernetes] 3:[kubernetes]]" {
+		t.Errorf("Got repo tags %v after the merge", tags)
+	}
+	if others := c.DB.GetAllRepoTagsMap("someone"); fmt.Sprint(others) != "map[1:[k8s]]" {
+		t.Errorf("Got repo tags %v for another user after the merge", others)
+	}
+	if recommended := c.DB.GetRecommendationTagByLanguage("Go", 10, 1); fmt.Sprint(recommended) != "[{kubernetes 3 1} {k8s 1 1}]" {
+		t.Errorf("Got language tags %v after the merge", recommended)
+	}
+}
+
+func TestRenameUserTagToItself(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	conn, err := gorm.Open("postgres", sqlDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	memory := database.NewMemory()
+	memory.InsertRepoTagsValue(database.RepoTag{UserID: "joaopmgd", RepoID: 1, TagName: "go"})
+	backends := map[string]database.Store{"memory": memory, "postgres": &database.Gorm{Conn: conn}}
+	for backend, store := range backends {
+		c := newTestConfig(t)
+		c.DB = store
+
+		response := executeHandlerTest(c, RenameUserTag, "PATCH", "/", `{"tag": "go"}`, map[string]string{"user": "joaopmgd", "tag": "go"})
+
+		if response.Code != http.StatusBadRequest || response.Body.String() != `{"error":"The new tag must be different from go"}` {
+			t.Errorf("\nTest %s\nGot Status %v and Body %s\nWant Status %v", backend, response.Code, response.Body.String(), http.StatusBadRequest)
+		}
+	}
+	// The tags are not touched, so no statement reaches the database
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+	if tags := memory.GetAllRepoTagsMap("joaopmgd"); fmt.Sprint(tags) != "map[1:[go]]" {
+		t.Errorf("Got repo tags %v, want the go tag kept", tags)
+	}
+}
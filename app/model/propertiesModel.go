@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // RequestError will detail the error encountered with the github API
 type RequestError struct {
 	Message          string `json:"message"`
@@ -8,22 +10,52 @@ type RequestError struct {
 
 // StarredRepoRequest is the starred repo complete data
 type StarredRepoRequest struct {
-	ID          int64  `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	URL         string `json:"url"`
-	Language    string `json:"language"`
+	ID          int64     `json:"id"`
+	FullName    string    `json:"full_name"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	URL         string    `json:"url"`
+	Language    string    `json:"language"`
+	Topics      []string  `json:"topics"`
+	Stars       int       `json:"stargazers_count"`
+	StarredAt   time.Time `json:"starred_at"`
 	RequestError
 }
 
 // StarredRepoTags is the starred repo complete data with tags that were added
 type StarredRepoTags struct {
-	ID          int64    `json:"id"`
-	Name        string   `json:"name"`
-	Description string   `json:"description"`
-	URL         string   `json:"url"`
-	Language    string   `json:"language"`
-	Tags        []string `json:"tags"`
+	ID          int64             `json:"id"`
+	FullName    string            `json:"full_name"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	URL         string            `json:"url"`
+	Language    string            `json:"language"`
+	Tags        []string          `json:"tags"`
+	Topics      []string          `json:"topics"`
+	Stars       int               `json:"stargazers_count"`
+	StarredAt   time.Time         `json:"starred_at"`
+	TagSources  map[string]string `json:"tag_sources,omitempty"`
+	TagRules    map[string]uint   `json:"tag_rules,omitempty"`
+}
+
+// ExportedRepo is a starred repo with its tags, as written by the export
+type ExportedRepo struct {
+	ID          int64    `json:"id" yaml:"id"`
+	FullName    string   `json:"full_name" yaml:"full_name"`
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description" yaml:"description"`
+	URL         string   `json:"url" yaml:"url"`
+	Language    string   `json:"language" yaml:"language"`
+	Tags        []string `json:"tags" yaml:"tags"`
+	Topics      []string `json:"topics" yaml:"topics"`
+	// StarredAt is left out when Github did not send it
+	StarredAt *time.Time `json:"starred_at,omitempty" yaml:"starred_at,omitempty"`
+}
+
+// AmbiguousRepoError lists the full names of the starred repos that have the requested name
+type AmbiguousRepoError struct {
+	Error      string   `json:"error"`
+	Candidates []string `json:"candidates"`
 }
 
 // TagRequestUpdate is the body from the tag request POST
@@ -36,9 +68,18 @@ type ResponseOK struct {
 	Message string `json:"Message"`
 }
 
-// RecommendedTags list of Recommended tags for a certain language
+// RecommendedTags list of Recommended tags for a certain language, ordered by usage
 type RecommendedTags struct {
-	Recommended []string `json:"recommended"`
+	Recommended []string         `json:"recommended"`
+	Counts      []RecommendedTag `json:"counts"`
+	Suggestions []TagSuggestion  `json:"suggestions"`
+}
+
+// RecommendedTag is a recommended tag with how many distinct repos and users applied it
+type RecommendedTag struct {
+	Tag   string `json:"tag"`
+	Repos int    `json:"repos"`
+	Users int    `json:"users"`
 }
 
 // StarredRepoTagsResponse has the pagination and RESTful data added to the response list
@@ -47,6 +88,150 @@ type StarredRepoTagsResponse struct {
 	PageNumber           int               `json:"page_number"`
 	PageSize             int               `json:"page_size"`
 	PropertiesTotalCount int               `json:"properties_total_count"`
+	PageLinks
+}
+
+// StarredRepoSearchResult is a starred repo that matched the search, with its score and the matched fields highlighted
+type StarredRepoSearchResult struct {
+	StarredRepoTags
+	Score      float64             `json:"score"`
+	Highlights map[string][]string `json:"highlights"`
+}
+
+// StarredRepoSearchResponse has the search results ranked by relevance, with the pagination
+type StarredRepoSearchResponse struct {
+	Query                string                    `json:"query"`
+	Results              []StarredRepoSearchResult `json:"results"`
+	PageNumber           int                       `json:"page_number"`
+	PageSize             int                       `json:"page_size"`
+	PropertiesTotalCount int                       `json:"properties_total_count"`
+	PageLinks
+}
+
+// PageLinks are the URLs of the pages around the current one, with an opaque cursor
+type PageLinks struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// TagSuggestion is a recommended tag with its score and the reasons it was suggested
+type TagSuggestion struct {
+	Tag     string             `json:"tag"`
+	Score   float64            `json:"score"`
+	Reasons []SuggestionReason `json:"reasons"`
+}
+
+// SuggestionReason explains why a tag was suggested, the source can be language, co_occurrence or other_users
+type SuggestionReason struct {
+	Source string `json:"source"`
+	Detail string `json:"detail"`
+	Count  int    `json:"count"`
+}
+
+// TagUsage is a tag used by an user, with how many repos have it
+type TagUsage struct {
+	Tag         string    `json:"tag"`
+	RepoCount   int       `json:"repo_count"`
+	FirstUsedAt time.Time `json:"first_used_at"`
+	LastUsedAt  time.Time `json:"last_used_at"`
+}
+
+// TagUsageResponse has the pagination added to the user tags list
+type TagUsageResponse struct {
+	Tags                 []TagUsage `json:"tags"`
+	PageNumber           int        `json:"page_number"`
+	PageSize             int        `json:"page_size"`
+	PropertiesTotalCount int        `json:"properties_total_count"`
+	PageLinks
+}
+
+// TagMergeRequest is the body to merge several tags into one
+type TagMergeRequest struct {
+	Tags []string `json:"tags"`
+	Into string   `json:"into"`
+}
+
+// TagChangeResponse tells how many repos were changed by a rename or merge
+type TagChangeResponse struct {
+	Message string `json:"Message"`
+	Tag     string `json:"tag"`
+	Repos   int    `json:"repos"`
+}
+
+// BulkTagOperation adds and removes tags of a single repo
+type BulkTagOperation struct {
+	RepoID int64    `json:"repo_id"`
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+// BulkTagRequest is the body of the bulk tagging request
+type BulkTagRequest struct {
+	Operations []BulkTagOperation `json:"operations"`
+}
+
+// BulkTagResult is the outcome of each bulk operation
+type BulkTagResult struct {
+	RepoID  int64    `json:"repo_id"`
+	Status  string   `json:"status"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// BulkTagResponse tells if the bulk operations were applied, with the result of each one
+type BulkTagResponse struct {
+	Applied bool            `json:"applied"`
+	Results []BulkTagResult `json:"results"`
+}
+
+// ImportRowResult is the outcome of each imported row, the rows are numbered from 1 without the CSV header
+type ImportRowResult struct {
+	Row      int      `json:"row"`
+	RepoID   int64    `json:"repo_id,omitempty"`
+	FullName string   `json:"full_name,omitempty"`
+	Status   string   `json:"status"`
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// ImportResponse counts the rows by status, with the result of each one
+type ImportResponse struct {
+	Strategy  string            `json:"strategy"`
+	Applied   int               `json:"applied"`
+	Unchanged int               `json:"unchanged"`
+	Skipped   int               `json:"skipped"`
+	Invalid   int               `json:"invalid"`
+	Rows      []ImportRowResult `json:"rows"`
+}
+
+// AutoTagRule tags the starred repos matching every condition
+type AutoTagRule struct {
+	ID         uint            `json:"id"`
+	Name       string          `json:"name"`
+	Tag        string          `json:"tag"`
+	Conditions []RuleCondition `json:"conditions"`
+}
+
+// RuleCondition compares a field of the repo with a value, as "language equals Rust"
+type RuleCondition struct {
+	Field    string `json:"field"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+// RuleDryRunResponse lists the repos a rule matches, without tagging them
+type RuleDryRunResponse struct {
+	Rule    AutoTagRule `json:"rule"`
+	Matches []RuleMatch `json:"matches"`
+}
+
+// RuleMatch is a repo matched by a rule, already tagged if it has the rule tag
+type RuleMatch struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	AlreadyTagged bool   `json:"already_tagged"`
 }
 
 // GithubHealthStatus stores the health status from github
@@ -60,11 +245,103 @@ type GithubStatus struct {
 	Description string `json:"description"`
 }
 
+// GithubUser is the Github user that owns a token
+type GithubUser struct {
+	Login string `json:"login"`
+	ID    int64  `json:"id"`
+}
+
+// GithubDeviceCode starts the OAuth device flow, the user types UserCode in VerificationURI
+type GithubDeviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// GithubDeviceToken is the token of the OAuth device flow, Error is set while the user did not authorize it
+type GithubDeviceToken struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	Scope            string `json:"scope"`
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"error_description,omitempty"`
+	Interval         int    `json:"interval,omitempty"`
+}
+
+// DeviceTokenRequest is the body to exchange a device code for a token
+type DeviceTokenRequest struct {
+	DeviceCode string `json:"device_code"`
+}
+
+// DeviceTokenResponse is the token of an authorized device code, with the login it belongs to
+type DeviceTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	Scope       string `json:"scope"`
+	Login       string `json:"login"`
+}
+
+// DeviceAuthPending tells the device code was not authorized yet, it should be polled again after Interval seconds
+type DeviceAuthPending struct {
+	Status   string `json:"status"`
+	Interval int    `json:"interval"`
+}
+
+// UserSettings are the preferences of an user, private users only have their tags read by themselves and the admins
+type UserSettings struct {
+	Private bool `json:"private"`
+}
+
+// APIKeyRequest creates an API key, the scopes are read (default), write and admin
+type APIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKey describes an API key of an user, the key itself is only shown when it is created
+type APIKey struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	Expired    bool       `json:"expired"`
+}
+
+// APIKeyCreated is a new API key, Key must be kept by the user as it can not be recovered
+type APIKeyCreated struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// GithubRateLimit is the Github quota reported by the X-RateLimit headers
+type GithubRateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+	Tokens    int       `json:"tokens"`
+}
+
 // AppHealthStatus shows the app health status
 type AppHealthStatus struct {
-	Status       string         `json:"status"`
-	GithubStatus GithubStatus   `json:"github"`
-	Database     DatabaseStatus `json:"database"`
+	Status          string          `json:"status"`
+	GithubStatus    GithubStatus    `json:"github"`
+	GithubRateLimit GithubRateLimit `json:"github_rate_limit"`
+	Database        DatabaseStatus  `json:"database"`
+	Mirrors         []MirrorStatus  `json:"mirrors,omitempty"`
+}
+
+// MirrorStatus details how stale the starred repos mirror of an user is
+type MirrorStatus struct {
+	User         string    `json:"user"`
+	SyncedAt     time.Time `json:"synced_at"`
+	StaleSeconds int64     `json:"stale_seconds"`
+	Error        string    `json:"error,omitempty"`
 }
 
 // DatabaseStatus detaisl the database health status
@@ -0,0 +1,203 @@
+package recommend
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// stopWords are too common in descriptions to tell the repos apart
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "in": true, "into": true, "is": true, "it": true, "its": true, "of": true,
+	"on": true, "or": true, "that": true, "the": true, "this": true, "to": true, "with": true, "your": true,
+	"you": true, "written": true, "based": true, "using": true, "simple": true,
+}
+
+// Document is a tagged repo, indexed by its name and description
+type Document struct {
+	ID   int64
+	Text string
+	Tags []string
+}
+
+// Neighbour is an indexed document similar to the searched text
+type Neighbour struct {
+	Document
+	Similarity float64
+}
+
+// Suggestion is a tag of the neighbours, scored by the sum of their similarities
+type Suggestion struct {
+	Tag        string
+	Score      float64
+	Neighbours []Neighbour
+}
+
+// Index is a TF-IDF index of the documents, it runs in memory so it needs no external service
+type Index struct {
+	documents []Document
+	vectors   []map[string]float64
+	idf       map[string]float64
+}
+
+// Token is a term of a text, Start and End are its byte offsets in the text
+type Token struct {
+	Term  string
+	Start int
+	End   int
+}
+
+// Tokenize splits the text in lower case terms, breaking words on punctuation and camelCase,
+// so "go-chi/chi", "HTTPRouter" and "http_router" share terms. Stop words and single letters are dropped.
+func Tokenize(text string) []string {
+	var terms []string
+	for _, token := range Tokens(text) {
+		terms = append(terms, token.Term)
+	}
+	return terms
+}
+
+// Tokens splits the text like Tokenize, keeping where each term is so it can be highlighted
+func Tokens(text string) []Token {
+	var tokens []Token
+	var current []rune
+	start := 0
+	flush := func(end int) {
+		if len(current) > 1 {
+			term := strings.ToLower(string(current))
+			if !stopWords[term] {
+				tokens = append(tokens, Token{Term: term, Start: start, End: end})
+			}
+		}
+		current = current[:0]
+	}
+	runes := []rune(text)
+	// offsets has the byte offset of each rune, plus the end of the text
+	offsets := make([]int, 0, len(runes)+1)
+	for offset := range text {
+		offsets = append(offsets, offset)
+	}
+	offsets = append(offsets, len(text))
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush(offsets[i])
+			continue
+		}
+		// A new word starts on a lower to upper case change, or on the last upper case letter of an acronym
+		if len(current) > 0 && unicode.IsUpper(r) {
+			previous := runes[i-1]
+			if unicode.IsLower(previous) || (unicode.IsUpper(previous) && i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				flush(offsets[i])
+			}
+		}
+		if len(current) == 0 {
+			start = offsets[i]
+		}
+		current = append(current, r)
+	}
+	flush(len(text))
+	return tokens
+}
+
+// NewIndex builds the index of the documents, the ones without tags are ignored since they can not suggest anything
+func NewIndex(documents []Document) *Index {
+	index := &Index{idf: make(map[string]float64)}
+	var termCounts []map[string]int
+	documentFrequency := make(map[string]int)
+	for _, document := range documents {
+		if len(document.Tags) == 0 {
+			continue
+		}
+		counts := make(map[string]int)
+		for _, term := range Tokenize(document.Text) {
+			counts[term]++
+		}
+		for term := range counts {
+			documentFrequency[term]++
+		}
+		index.documents = append(index.documents, document)
+		termCounts = append(termCounts, counts)
+	}
+	total := float64(len(index.documents))
+	for term, frequency := range documentFrequency {
+		index.idf[term] = math.Log((1+total)/(1+float64(frequency))) + 1
+	}
+	for _, counts := range termCounts {
+		index.vectors = append(index.vectors, index.vector(counts))
+	}
+	return index
+}
+
+// vector weights the term counts by TF-IDF, normalized to length one. Terms unknown to the index are ignored.
+func (index *Index) vector(counts map[string]int) map[string]float64 {
+	vector := make(map[string]float64)
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	var norm float64
+	for term, count := range counts {
+		idf, found := index.idf[term]
+		if !found {
+			continue
+		}
+		weight := float64(count) / float64(total) * idf
+		vector[term] = weight
+		norm += weight * weight
+	}
+	norm = math.Sqrt(norm)
+	for term := range vector {
+		vector[term] /= norm
+	}
+	return vector
+}
+
+// Neighbours returns up to k documents most similar to the text, by the cosine of their vectors,
+// ignoring the document with the excluded id and the ones sharing no term
+func (index *Index) Neighbours(text string, k int, excludedID int64) []Neighbour {
+	counts := make(map[string]int)
+	for _, term := range Tokenize(text) {
+		counts[term]++
+	}
+	query := index.vector(counts)
+	var neighbours []Neighbour
+	for i, vector := range index.vectors {
+		if index.documents[i].ID == excludedID {
+			continue
+		}
+		var similarity float64
+		for term, weight := range query {
+			similarity += weight * vector[term]
+		}
+		if similarity > 0 {
+			neighbours = append(neighbours, Neighbour{Document: index.documents[i], Similarity: similarity})
+		}
+	}
+	sort.SliceStable(neighbours, func(i, j int) bool { return neighbours[i].Similarity > neighbours[j].Similarity })
+	if len(neighbours) > k {
+		neighbours = neighbours[:k]
+	}
+	return neighbours
+}
+
+// SuggestTags scores the tags of the k nearest neighbours of the text, the best ones first
+func (index *Index) SuggestTags(text string, k int, excludedID int64) []Suggestion {
+	var suggestions []Suggestion
+	position := make(map[string]int)
+	for _, neighbour := range index.Neighbours(text, k, excludedID) {
+		for _, tag := range neighbour.Tags {
+			i, found := position[tag]
+			if !found {
+				i = len(suggestions)
+				position[tag] = i
+				suggestions = append(suggestions, Suggestion{Tag: tag})
+			}
+			suggestions[i].Score += neighbour.Similarity
+			suggestions[i].Neighbours = append(suggestions[i].Neighbours, neighbour)
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool { return suggestions[i].Score > suggestions[j].Score })
+	return suggestions
+}
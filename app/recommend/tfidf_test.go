@@ -0,0 +1,73 @@
+package recommend
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tt := map[string]struct {
+		text  string
+		terms []string
+	}{
+		"empty":             {"", nil},
+		"stop_words":        {"A router for the web", []string{"router", "web"}},
+		"punctuation":       {"go-chi/chi: lightweight, idiomatic router", []string{"go", "chi", "chi", "lightweight", "idiomatic", "router"}},
+		"camel_case":        {"HTTPRouter fastHttp", []string{"http", "router", "fast", "http"}},
+		"snake_case_digits": {"http_router v2", []string{"http", "router", "v2"}},
+	}
+	for testName, tc := range tt {
+
+		terms := Tokenize(tc.text)
+
+		if !reflect.DeepEqual(terms, tc.terms) {
+			t.Errorf("\nTest %s\nGot %v\nWant %v", testName, terms, tc.terms)
+		}
+	}
+}
+
+func TestTokens(t *testing.T) {
+	text := "Ünïcode HTTPRouter, the_end"
+
+	tokens := Tokens(text)
+
+	var spans []string
+	for _, token := range tokens {
+		spans = append(spans, token.Term+"="+text[token.Start:token.End])
+	}
+	want := []string{"ünïcode=Ünïcode", "http=HTTP", "router=Router", "end=end"}
+	if !reflect.DeepEqual(spans, want) {
+		t.Errorf("\nGot %v\nWant %v", spans, want)
+	}
+}
+
+func TestSuggestTags(t *testing.T) {
+	index := NewIndex([]Document{
+		{ID: 1, Text: "mux A powerful HTTP router and URL matcher", Tags: []string{"router", "http"}},
+		{ID: 2, Text: "chi lightweight idiomatic composable router for building HTTP services", Tags: []string{"router"}},
+		{ID: 3, Text: "tokio A runtime for writing reliable asynchronous applications", Tags: []string{"async"}},
+		{ID: 4, Text: "httprouter A high performance HTTP request router", Tags: nil},
+	})
+	tt := map[string]struct {
+		text       string
+		excludedID int64
+		expected   string
+	}{
+		"similar_routers": {"gin HTTP web framework with a fast router", 0, "[router http]"},
+		"async_runtime":   {"async-std asynchronous runtime", 0, "[async]"},
+		"excluded_self":   {"mux A powerful HTTP router and URL matcher", 1, "[router]"},
+		"nothing_shared":  {"machine learning notebooks", 0, "[]"},
+	}
+	for testName, tc := range tt {
+
+		var tags []string
+		for _, suggestion := range index.SuggestTags(tc.text, 5, tc.excludedID) {
+			tags = append(tags, suggestion.Tag)
+		}
+
+		if fmt.Sprint(tags) != tc.expected {
+			t.Errorf("\nTest %s\nGot %v\nWant %s", testName, tags, tc.expected)
+		}
+	}
+}
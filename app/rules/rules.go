@@ -0,0 +1,116 @@
+package rules
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/joaopmgd/github-tag-api/app/model"
+)
+
+// Fields of the repo that can be compared
+const (
+	FieldName        = "name"
+	FieldDescription = "description"
+	FieldLanguage    = "language"
+	FieldTopic       = "topic"
+)
+
+// Operators that compare a field with the value, every one but matches ignores the case
+const (
+	OperatorEquals    = "equals"
+	OperatorNotEquals = "not_equals"
+	OperatorContains  = "contains"
+	OperatorPrefix    = "prefix"
+	OperatorSuffix    = "suffix"
+	OperatorMatches   = "matches"
+)
+
+var fields = map[string]bool{FieldName: true, FieldDescription: true, FieldLanguage: true, FieldTopic: true}
+
+var operators = map[string]bool{
+	OperatorEquals: true, OperatorNotEquals: true, OperatorContains: true,
+	OperatorPrefix: true, OperatorSuffix: true, OperatorMatches: true,
+}
+
+// Validate checks that the rule has a tag and that every condition can be evaluated
+func Validate(rule model.AutoTagRule) error {
+	if strings.TrimSpace(rule.Tag) == "" {
+		return errors.New("Rule must have a tag")
+	}
+	if len(rule.Conditions) == 0 {
+		return errors.New("Rule must have at least one condition")
+	}
+	for _, condition := range rule.Conditions {
+		if !fields[condition.Field] {
+			return errors.New("Condition field must be one of: name, description, language, topic")
+		}
+		if !operators[condition.Operator] {
+			return errors.New("Condition operator must be one of: equals, not_equals, contains, prefix, suffix, matches")
+		}
+		if condition.Operator == OperatorMatches {
+			if _, err := regexp.Compile(condition.Value); err != nil {
+				return errors.New("Condition value is not a valid regular expression: " + condition.Value)
+			}
+		}
+	}
+	return nil
+}
+
+// Matches tells if the repo passes every condition of the rule
+func Matches(rule model.AutoTagRule, repo model.StarredRepoRequest) bool {
+	for _, condition := range rule.Conditions {
+		if !conditionMatches(condition, repo) {
+			return false
+		}
+	}
+	return true
+}
+
+// conditionMatches compares the field of the repo, a topic condition passes if any topic passes
+func conditionMatches(condition model.RuleCondition, repo model.StarredRepoRequest) bool {
+	switch condition.Field {
+	case FieldName:
+		return compare(condition, repo.Name)
+	case FieldDescription:
+		return compare(condition, repo.Description)
+	case FieldLanguage:
+		return compare(condition, repo.Language)
+	case FieldTopic:
+		if condition.Operator == OperatorNotEquals {
+			for _, topic := range repo.Topics {
+				if !compare(condition, topic) {
+					return false
+				}
+			}
+			return true
+		}
+		for _, topic := range repo.Topics {
+			if compare(condition, topic) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func compare(condition model.RuleCondition, field string) bool {
+	if condition.Operator == OperatorMatches {
+		pattern, err := regexp.Compile(condition.Value)
+		return err == nil && pattern.MatchString(field)
+	}
+	field, value := strings.ToLower(field), strings.ToLower(condition.Value)
+	switch condition.Operator {
+	case OperatorEquals:
+		return field == value
+	case OperatorNotEquals:
+		return field != value
+	case OperatorContains:
+		return strings.Contains(field, value)
+	case OperatorPrefix:
+		return strings.HasPrefix(field, value)
+	case OperatorSuffix:
+		return strings.HasSuffix(field, value)
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package rules
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/joaopmgd/github-tag-api/app/model"
+)
+
+func condition(field, operator, value string) model.RuleCondition {
+	return model.RuleCondition{Field: field, Operator: operator, Value: value}
+}
+
+func TestMatches(t *testing.T) {
+	ripgrep := model.StarredRepoRequest{Name: "ripgrep", Description: "A line-oriented search CLI tool", Language: "Rust", Topics: []string{"cli", "search"}}
+	tt := map[string]struct {
+		conditions []model.RuleCondition
+		matches    bool
+	}{
+		"language_and_description": {[]model.RuleCondition{condition(FieldLanguage, OperatorEquals, "rust"), condition(FieldDescription, OperatorContains, "cli")}, true},
+		"one_condition_fails":      {[]model.RuleCondition{condition(FieldLanguage, OperatorEquals, "Go"), condition(FieldDescription, OperatorContains, "cli")}, false},
+		"name_prefix":              {[]model.RuleCondition{condition(FieldName, OperatorPrefix, "rip")}, true},
+		"name_suffix":              {[]model.RuleCondition{condition(FieldName, OperatorSuffix, "grep")}, true},
+		"not_equals":               {[]model.RuleCondition{condition(FieldLanguage, OperatorNotEquals, "Go")}, true},
+		"any_topic":                {[]model.RuleCondition{condition(FieldTopic, OperatorEquals, "search")}, true},
+		"no_topic":                 {[]model.RuleCondition{condition(FieldTopic, OperatorNotEquals, "cli")}, false},
+		"regular_expression":       {[]model.RuleCondition{condition(FieldDescription, OperatorMatches, `\bCLI\b`)}, true},
+		"case_sensitive_regexp":    {[]model.RuleCondition{condition(FieldDescription, OperatorMatches, `\bcli\b`)}, false},
+	}
+	for testName, tc := range tt {
+
+		matches := Matches(model.AutoTagRule{Tag: "rust-cli", Conditions: tc.conditions}, ripgrep)
+
+		if matches != tc.matches {
+			t.Errorf("\nTest %s\nGot %s\nWant %s", testName, strconv.FormatBool(matches), strconv.FormatBool(tc.matches))
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := []model.RuleCondition{condition(FieldLanguage, OperatorEquals, "Rust")}
+	tt := map[string]struct {
+		rule        model.AutoTagRule
+		expectError bool
+	}{
+		"valid":            {model.AutoTagRule{Tag: "rust", Conditions: valid}, false},
+		"no_tag":           {model.AutoTagRule{Tag: " ", Conditions: valid}, true},
+		"no_conditions":    {model.AutoTagRule{Tag: "rust"}, true},
+		"unknown_field":    {model.AutoTagRule{Tag: "rust", Conditions: []model.RuleCondition{condition("stars", OperatorEquals, "1")}}, true},
+		"unknown_operator": {model.AutoTagRule{Tag: "rust", Conditions: []model.RuleCondition{condition(FieldName, "like", "x")}}, true},
+		"invalid_regexp":   {model.AutoTagRule{Tag: "rust", Conditions: []model.RuleCondition{condition(FieldName, OperatorMatches, "(")}}, true},
+	}
+	for testName, tc := range tt {
+
+		err := Validate(tc.rule)
+
+		if (err != nil) != tc.expectError {
+			t.Errorf("\nTest %s\nGot error %v, want error %s", testName, err, strconv.FormatBool(tc.expectError))
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package search
+
+import (
+	"html"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/joaopmgd/github-tag-api/app/recommend"
+)
+
+// Fields of a document that are searched
+const (
+	FieldName        = "name"
+	FieldDescription = "description"
+	FieldLanguage    = "language"
+	FieldTags        = "tags"
+)
+
+// fields are searched in this order, so the scores are summed always in the same order
+var fields = []string{FieldName, FieldDescription, FieldLanguage, FieldTags}
+
+// fieldWeights tells how much a match in each field is worth, a name match is the strongest hint
+var fieldWeights = map[string]float64{
+	FieldName:        3,
+	FieldTags:        2,
+	FieldLanguage:    1.5,
+	FieldDescription: 1,
+}
+
+// prefixWeight is how much a term that only starts with the query term is worth, as "rout" in "router"
+const prefixWeight = 0.5
+
+// minPrefixLength avoids matching most of the terms with very short query terms
+const minPrefixLength = 3
+
+// HighlightStart and HighlightEnd wrap the matched terms in the highlights
+const (
+	HighlightStart = "<em>"
+	HighlightEnd   = "</em>"
+)
+
+// Document is a starred repo with its tags
+type Document struct {
+	ID          int64
+	Name        string
+	Description string
+	Language    string
+	Tags        []string
+}
+
+// Result is a document that matched the query, the highlights have the matched fields with their terms wrapped
+type Result struct {
+	ID         int64
+	Score      float64
+	Highlights map[string][]string
+}
+
+// Search ranks the documents matching any term of the query by the sum, for each term, of its best match weight in each field
+// times how rare the term is among the documents. The ties keep the documents order.
+func Search(documents []Document, query string) []Result {
+	terms := unique(recommend.Tokenize(query))
+	if len(terms) == 0 {
+		return []Result{}
+	}
+	tokenized := make([]map[string][]recommend.Token, len(documents))
+	frequency := make(map[string]int)
+	for i, document := range documents {
+		tokenized[i] = map[string][]recommend.Token{
+			FieldName:        recommend.Tokens(document.Name),
+			FieldDescription: recommend.Tokens(document.Description),
+			FieldLanguage:    recommend.Tokens(document.Language),
+			FieldTags:        recommend.Tokens(strings.Join(document.Tags, " ")),
+		}
+		for _, term := range terms {
+			for _, tokens := range tokenized[i] {
+				if bestMatch(tokens, term) > 0 {
+					frequency[term]++
+					break
+				}
+			}
+		}
+	}
+
+	results := []Result{}
+	for i, document := range documents {
+		result := Result{ID: document.ID, Highlights: make(map[string][]string)}
+		for _, term := range terms {
+			if frequency[term] == 0 {
+				continue
+			}
+			idf := math.Log(1 + float64(len(documents))/float64(frequency[term]))
+			for _, field := range fields {
+				result.Score += fieldWeights[field] * bestMatch(tokenized[i][field], term) * idf
+			}
+		}
+		if result.Score == 0 {
+			continue
+		}
+		result.Score = math.Round(result.Score*1000) / 1000
+		for field, text := range map[string]string{FieldName: document.Name, FieldDescription: document.Description, FieldLanguage: document.Language} {
+			if highlighted, found := highlight(text, terms); found {
+				result.Highlights[field] = []string{highlighted}
+			}
+		}
+		for _, tag := range document.Tags {
+			if highlighted, found := highlight(tag, terms); found {
+				result.Highlights[FieldTags] = append(result.Highlights[FieldTags], highlighted)
+			}
+		}
+		results = append(results, result)
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	return results
+}
+
+// bestMatch is 1 when a token is the term, prefixWeight when a token starts with it and 0 otherwise
+func bestMatch(tokens []recommend.Token, term string) float64 {
+	best := 0.0
+	for _, token := range tokens {
+		if token.Term == term {
+			return 1
+		}
+		if len(term) >= minPrefixLength && strings.HasPrefix(token.Term, term) {
+			best = prefixWeight
+		}
+	}
+	return best
+}
+
+// highlight wraps the terms of the text that match any query term, false when none matches.
+// The text is HTML escaped, as it comes from the repo owners and the highlights are rendered as HTML.
+func highlight(text string, terms []string) (string, bool) {
+	var highlighted strings.Builder
+	last := 0
+	for _, token := range recommend.Tokens(text) {
+		for _, term := range terms {
+			if bestMatch([]recommend.Token{token}, term) > 0 {
+				highlighted.WriteString(html.EscapeString(text[last:token.Start]))
+				highlighted.WriteString(HighlightStart + html.EscapeString(text[token.Start:token.End]) + HighlightEnd)
+				last = token.End
+				break
+			}
+		}
+	}
+	if last == 0 {
+		return "", false
+	}
+	highlighted.WriteString(html.EscapeString(text[last:]))
+	return highlighted.String(), true
+}
+
+func unique(terms []string) []string {
+	var selected []string
+	seen := make(map[string]bool)
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			selected = append(selected, term)
+		}
+	}
+	return selected
+}
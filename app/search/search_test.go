@@ -0,0 +1,67 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	documents := []Document{
+		{ID: 1, Name: "mux", Description: "A powerful HTTP router and URL matcher", Language: "Go", Tags: []string{"router", "web"}},
+		{ID: 2, Name: "httprouter", Description: "A high performance HTTP request router", Language: "Go"},
+		{ID: 3, Name: "tokio", Description: "A runtime for writing reliable asynchronous applications", Language: "Rust", Tags: []string{"async"}},
+	}
+	tt := map[string]struct {
+		query string
+		ids   []int64
+	}{
+		"empty_query":      {"", []int64{}},
+		"stop_words_only":  {"the a", []int64{}},
+		"no_match":         {"python", []int64{}},
+		"name_first":       {"httprouter", []int64{2}},
+		"any_term":         {"that HTTP router I starred", []int64{1, 2}},
+		"tags_and_names":   {"async runtime", []int64{3}},
+		"prefix":           {"asyn", []int64{3}},
+		"language":         {"rust", []int64{3}},
+		"short_not_prefix": {"ru", []int64{}},
+	}
+	for testName, tc := range tt {
+
+		results := Search(documents, tc.query)
+
+		ids := []int64{}
+		for _, result := range results {
+			ids = append(ids, result.ID)
+		}
+		if !reflect.DeepEqual(ids, tc.ids) {
+			t.Errorf("\nTest %s\nGot %v\nWant %v", testName, ids, tc.ids)
+		}
+	}
+}
+
+func TestSearchHighlights(t *testing.T) {
+	documents := []Document{{ID: 1, Name: "mux", Description: "A powerful HTTP router", Language: "Go", Tags: []string{"http-router", "web"}}}
+
+	results := Search(documents, "router http")
+
+	want := map[string][]string{
+		FieldDescription: {"A powerful <em>HTTP</em> <em>router</em>"},
+		FieldTags:        {"<em>http</em>-<em>router</em>"},
+	}
+	if len(results) != 1 || !reflect.DeepEqual(results[0].Highlights, want) {
+		t.Errorf("\nGot %+v\nWant highlights %v", results, want)
+	}
+}
+
+func TestSearchHighlightsEscaped(t *testing.T) {
+	documents := []Document{{ID: 1, Name: "xss", Description: `<script>alert("router")</script> & <img src=x onerror=router>`}}
+
+	results := Search(documents, "router")
+
+	want := map[string][]string{
+		FieldDescription: {`&lt;script&gt;alert(&#34;<em>router</em>&#34;)&lt;/script&gt; &amp; &lt;img src=x onerror=<em>router</em>&gt;`},
+	}
+	if len(results) != 1 || !reflect.DeepEqual(results[0].Highlights, want) {
+		t.Errorf("\nGot %+v\nWant highlights %v", results, want)
+	}
+}
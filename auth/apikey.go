@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/joaopmgd/github-tag-api/database"
+)
+
+// KeyPrefix starts every API key, so they are told apart from the Github tokens
+const KeyPrefix = "gta_"
+
+// keyDisplayLength is how much of the key is kept in plain text, so the user can recognize it in the list
+const keyDisplayLength = len(KeyPrefix) + 6
+
+// KeyStore finds the API keys by their hash
+type KeyStore interface {
+	GetAPIKeyByHash(hash string) (database.APIKey, bool)
+	TouchAPIKey(id uint, usedAt time.Time) error
+}
+
+// GenerateKey creates a random API key, returning it with the prefix that is kept in plain text
+func GenerateKey() (key, prefix string, err error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", "", err
+	}
+	key = KeyPrefix + base64.RawURLEncoding.EncodeToString(random)
+	return key, key[:keyDisplayLength], nil
+}
+
+// ParseScopes splits the scopes stored separated by commas
+func ParseScopes(scopes string) []string {
+	parsed := []string{}
+	for _, scope := range strings.Split(scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			parsed = append(parsed, scope)
+		}
+	}
+	return parsed
+}
+
+// ValidKey finds the API key of the credential, false when it was not issued, was revoked or expired
+func ValidKey(store KeyStore, credential string, now time.Time) (database.APIKey, bool) {
+	if !strings.HasPrefix(credential, KeyPrefix) {
+		return database.APIKey{}, false
+	}
+	key, found := store.GetAPIKeyByHash(Hash(credential))
+	if !found || (key.ExpiresAt != nil && !now.Before(*key.ExpiresAt)) {
+		return database.APIKey{}, false
+	}
+	return key, true
+}
+
+// KeyAuthenticator authenticates the API keys issued by the server, any other credential goes to the next authenticator
+type KeyAuthenticator struct {
+	store  KeyStore
+	next   Authenticator
+	admins map[string]bool
+}
+
+// NewKeyAuthenticator creates the authenticator, next is used for the credentials that are not API keys and may be nil
+func NewKeyAuthenticator(store KeyStore, admins []string, next Authenticator) *KeyAuthenticator {
+	return &KeyAuthenticator{store: store, next: next, admins: adminSet(admins)}
+}
+
+// Authenticate finds the user and scopes of the API key, the expired and revoked keys are invalid
+func (a *KeyAuthenticator) Authenticate(ctx context.Context, credential string) (Principal, error) {
+	if !strings.HasPrefix(credential, KeyPrefix) {
+		if a.next == nil {
+			return Principal{}, ErrInvalidCredentials
+		}
+		return a.next.Authenticate(ctx, credential)
+	}
+	now := time.Now()
+	key, valid := ValidKey(a.store, credential, now)
+	if !valid {
+		return Principal{}, ErrInvalidCredentials
+	}
+	if err := a.store.TouchAPIKey(key.ID, now); err != nil {
+		return Principal{}, err
+	}
+	return Principal{
+		Login:  key.UserID,
+		Admin:  a.admins[strings.ToLower(key.UserID)],
+		Method: MethodAPIKey,
+		Scopes: ParseScopes(key.Scopes),
+	}, nil
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joaopmgd/github-tag-api/database"
+)
+
+func TestPrincipalScopes(t *testing.T) {
+	tt := map[string]struct {
+		principal Principal
+		user      string
+		canRead   bool
+		canWrite  bool
+		canAdmin  bool
+	}{
+		"github_token":   {Principal{Login: "joaopmgd"}, "joaopmgd", true, true, true},
+		"read_key":       {Principal{Login: "joaopmgd", Scopes: []string{ScopeRead}}, "joaopmgd", true, false, false},
+		"write_key":      {Principal{Login: "joaopmgd", Scopes: []string{ScopeWrite}}, "joaopmgd", true, true, false},
+		"admin_key":      {Principal{Login: "joaopmgd", Scopes: []string{ScopeRead, ScopeAdmin}}, "joaopmgd", true, true, true},
+		"no_scopes":      {Principal{Login: "joaopmgd", Scopes: []string{}}, "joaopmgd", false, false, false},
+		"other_user":     {Principal{Login: "someone", Scopes: []string{ScopeAdmin}}, "joaopmgd", false, false, true},
+		"admin_read_key": {Principal{Login: "root", Admin: true, Scopes: []string{ScopeRead}}, "joaopmgd", true, false, false},
+	}
+	for testName, tc := range tt {
+
+		canRead, canWrite, canAdmin := tc.principal.CanRead(tc.user), tc.principal.CanWrite(tc.user), tc.principal.HasScope(ScopeAdmin)
+
+		if canRead != tc.canRead || canWrite != tc.canWrite || canAdmin != tc.canAdmin {
+			t.Errorf("\nTest %s\nGot read %v, write %v and admin scope %v\nWant read %v, write %v and admin scope %v",
+				testName, canRead, canWrite, canAdmin, tc.canRead, tc.canWrite, tc.canAdmin)
+		}
+	}
+}
+
+func TestGenerateKey(t *testing.T) {
+	key, prefix, err := GenerateKey()
+	other, _, _ := GenerateKey()
+
+	if err != nil || !strings.HasPrefix(key, KeyPrefix) || !strings.HasPrefix(key, prefix) || len(prefix) != keyDisplayLength || len(key) < 40 || key == other {
+		t.Errorf("Got key %s with prefix %s and error %v\nWant an unique random key starting with %s", key, prefix, err, KeyPrefix)
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	tt := map[string]struct {
+		scopes string
+		parsed []string
+	}{
+		"empty":  {"", []string{}},
+		"single": {"read", []string{"read"}},
+		"many":   {"read, write,,admin", []string{"read", "write", "admin"}},
+	}
+	for testName, tc := range tt {
+
+		parsed := ParseScopes(tc.scopes)
+
+		if !reflect.DeepEqual(parsed, tc.parsed) {
+			t.Errorf("\nTest %s\nGot %v\nWant %v", testName, parsed, tc.parsed)
+		}
+	}
+}
+
+func TestKeyAuthenticator(t *testing.T) {
+	store := database.NewMemory()
+	past := time.Now().Add(-time.Hour)
+	for _, key := range []database.APIKey{
+		{UserID: "joaopmgd", Hash: Hash("gta_valid"), Scopes: "read,write"},
+		{UserID: "Octocat", Hash: Hash("gta_admin"), Scopes: "admin"},
+		{UserID: "joaopmgd", Hash: Hash("gta_expired"), Scopes: "read", ExpiresAt: &past},
+		{UserID: "joaopmgd", Hash: Hash("gta_revoked"), Scopes: "read"},
+	} {
+		key := key
+		store.InsertAPIKey(&key)
+	}
+	store.RevokeAPIKey("joaopmgd", 4)
+	github := NewGithubAuthenticator(func(ctx context.Context, token string) (string, error) {
+		if token == "github-token" {
+			return "joaopmgd", nil
+		}
+		return "", ErrInvalidCredentials
+	}, nil)
+	authenticator := NewKeyAuthenticator(store, []string{"octocat"}, github)
+	tt := map[string]struct {
+		credential string
+		principal  Principal
+		err        error
+	}{
+		"valid_key":    {"gta_valid", Principal{Login: "joaopmgd", Method: MethodAPIKey, Scopes: []string{"read", "write"}}, nil},
+		"admin_key":    {"gta_admin", Principal{Login: "Octocat", Admin: true, Method: MethodAPIKey, Scopes: []string{"admin"}}, nil},
+		"unknown_key":  {"gta_unknown", Principal{}, ErrInvalidCredentials},
+		"expired_key":  {"gta_expired", Principal{}, ErrInvalidCredentials},
+		"revoked_key":  {"gta_revoked", Principal{}, ErrInvalidCredentials},
+		"github_token": {"github-token", Principal{Login: "joaopmgd", Method: MethodGithubToken}, nil},
+		"invalid":      {"other", Principal{}, ErrInvalidCredentials},
+	}
+	for testName, tc := range tt {
+
+		principal, err := authenticator.Authenticate(context.Background(), tc.credential)
+
+		if err != tc.err || !reflect.DeepEqual(principal, tc.principal) {
+			t.Errorf("\nTest %s\nGot %+v and error %v\nWant %+v and error %v", testName, principal, err, tc.principal, tc.err)
+		}
+	}
+
+	if keys := store.GetAPIKeys("joaopmgd"); len(keys) != 2 || keys[0].LastUsedAt == nil || keys[1].LastUsedAt != nil {
+		t.Errorf("Got keys %+v\nWant only the valid key marked as used", keys)
+	}
+}
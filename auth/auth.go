@@ -0,0 +1,217 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Methods used to authenticate a principal
+const (
+	MethodGithubToken = "github_token"
+	MethodAPIKey      = "api_key"
+)
+
+// Scopes limit what a principal can do, each one includes the ones before it
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+)
+
+// scopeLevels orders the scopes, a bigger level includes the smaller ones
+var scopeLevels = map[string]int{ScopeRead: 1, ScopeWrite: 2, ScopeAdmin: 3}
+
+// ErrInvalidCredentials is returned when the credentials are unknown, expired or revoked
+var ErrInvalidCredentials = errors.New("Invalid credentials")
+
+// DefaultCacheTTL is how long a validated token is trusted before asking Github again
+const DefaultCacheTTL = 5 * time.Minute
+
+// DefaultMaxCachedTokens caps how many validated tokens are cached, the one expiring first is removed to store a new one
+const DefaultMaxCachedTokens = 10000
+
+// cachePruneInterval is how often the expired tokens are removed from the cache
+const cachePruneInterval = time.Minute
+
+// Principal is the authenticated caller, mapped to a Github login.
+// Scopes are only set for API keys, a Github token has every scope.
+type Principal struct {
+	Login  string   `json:"login"`
+	Admin  bool     `json:"admin"`
+	Method string   `json:"method"`
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// Owns tells if the principal is the user itself or an admin
+func (p Principal) Owns(user string) bool {
+	return p.Admin || strings.EqualFold(p.Login, user)
+}
+
+// HasScope tells if any scope of the principal includes the scope
+func (p Principal) HasScope(scope string) bool {
+	if p.Scopes == nil {
+		return true
+	}
+	for _, granted := range p.Scopes {
+		if scopeLevels[granted] >= scopeLevels[scope] {
+			return true
+		}
+	}
+	return false
+}
+
+// CanWrite tells if the principal can change the tags of the user, only the user itself and the admins can
+func (p Principal) CanWrite(user string) bool {
+	return p.HasScope(ScopeWrite) && p.Owns(user)
+}
+
+// CanRead tells if the principal can read the private tags of the user
+func (p Principal) CanRead(user string) bool {
+	return p.HasScope(ScopeRead) && p.Owns(user)
+}
+
+// ValidScope tells if the scope is read, write or admin
+func ValidScope(scope string) bool {
+	_, found := scopeLevels[scope]
+	return found
+}
+
+// Authenticator finds the principal that owns a credential
+type Authenticator interface {
+	Authenticate(ctx context.Context, credential string) (Principal, error)
+}
+
+type contextKey int
+
+const principalKey contextKey = 0
+
+// WithPrincipal stores the authenticated principal in the request context
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+// FromContext recovers the authenticated principal, false for anonymous requests
+func FromContext(ctx context.Context) (Principal, bool) {
+	principal, found := ctx.Value(principalKey).(Principal)
+	return principal, found
+}
+
+// Credential reads the credential from the Authorization header, as "Bearer {credential}" or "token {credential}",
+// or the API key from the X-API-Key header
+func Credential(r *http.Request) string {
+	if key := strings.TrimSpace(r.Header.Get("X-API-Key")); key != "" {
+		return key
+	}
+	header := strings.TrimSpace(r.Header.Get("Authorization"))
+	for _, scheme := range []string{"bearer ", "token "} {
+		if len(header) > len(scheme) && strings.EqualFold(header[:len(scheme)], scheme) {
+			return strings.TrimSpace(header[len(scheme):])
+		}
+	}
+	return ""
+}
+
+// LoginFetcher asks Github which login owns the token, returning ErrInvalidCredentials when Github refuses it
+type LoginFetcher func(ctx context.Context, token string) (string, error)
+
+type cachedPrincipal struct {
+	principal Principal
+	expires   time.Time
+}
+
+// GithubAuthenticator authenticates Github tokens, from personal access tokens or the device flow.
+// The valid tokens are cached by their hash for TTL, so Github is not asked on every request.
+// At most MaxCachedTokens are cached, zero means no limit.
+type GithubAuthenticator struct {
+	TTL             time.Duration
+	MaxCachedTokens int
+
+	fetchLogin LoginFetcher
+	admins     map[string]bool
+	mu         sync.Mutex
+	cache      map[string]cachedPrincipal
+	lastPrune  time.Time
+}
+
+// NewGithubAuthenticator creates the authenticator, the admins are Github logins that can change the tags of every user
+func NewGithubAuthenticator(fetchLogin LoginFetcher, admins []string) *GithubAuthenticator {
+	a := &GithubAuthenticator{
+		TTL:             DefaultCacheTTL,
+		MaxCachedTokens: DefaultMaxCachedTokens,
+		fetchLogin:      fetchLogin,
+		admins:          adminSet(admins),
+		cache:           make(map[string]cachedPrincipal),
+	}
+	return a
+}
+
+// Authenticate finds the Github login of the token
+func (a *GithubAuthenticator) Authenticate(ctx context.Context, token string) (Principal, error) {
+	if token == "" {
+		return Principal{}, ErrInvalidCredentials
+	}
+	key := Hash(token)
+	a.mu.Lock()
+	cached, found := a.cache[key]
+	a.mu.Unlock()
+	if found && time.Now().Before(cached.expires) {
+		return cached.principal, nil
+	}
+	login, err := a.fetchLogin(ctx, token)
+	if err != nil {
+		return Principal{}, err
+	}
+	principal := Principal{Login: login, Admin: a.IsAdmin(login), Method: MethodGithubToken}
+	a.storePrincipal(key, principal, time.Now())
+	return principal, nil
+}
+
+// storePrincipal caches the principal of a token hash, removing the expired tokens and the one expiring first when the cache is full
+func (a *GithubAuthenticator) storePrincipal(key string, principal Principal, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if now.Sub(a.lastPrune) >= cachePruneInterval {
+		a.lastPrune = now
+		for cachedKey, cached := range a.cache {
+			if !now.Before(cached.expires) {
+				delete(a.cache, cachedKey)
+			}
+		}
+	}
+	if _, found := a.cache[key]; !found && a.MaxCachedTokens > 0 && len(a.cache) >= a.MaxCachedTokens {
+		first := ""
+		for cachedKey, cached := range a.cache {
+			if first == "" || cached.expires.Before(a.cache[first].expires) {
+				first = cachedKey
+			}
+		}
+		delete(a.cache, first)
+	}
+	a.cache[key] = cachedPrincipal{principal: principal, expires: now.Add(a.TTL)}
+}
+
+// IsAdmin tells if the login is one of the admins
+func (a *GithubAuthenticator) IsAdmin(login string) bool {
+	return a.admins[strings.ToLower(login)]
+}
+
+// adminSet indexes the admin logins ignoring the case
+func adminSet(admins []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, admin := range admins {
+		set[strings.ToLower(admin)] = true
+	}
+	return set
+}
+
+// Hash is the SHA-256 of a credential in hex, so the credentials are never kept in plain text
+func Hash(credential string) string {
+	sum := sha256.Sum256([]byte(credential))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCredential(t *testing.T) {
+	tt := map[string]struct {
+		header     string
+		credential string
+	}{
+		"bearer":         {"Bearer abc", "abc"},
+		"token":          {"token abc", "abc"},
+		"case":           {"BEARER  abc ", "abc"},
+		"missing":        {"", ""},
+		"basic":          {"Basic YTpi", ""},
+		"only_scheme":    {"Bearer ", ""},
+		"no_credentials": {"Bearer", ""},
+	}
+	for testName, tc := range tt {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Authorization", tc.header)
+
+		credential := Credential(r)
+
+		if credential != tc.credential {
+			t.Errorf("\nTest %s\nGot '%s'\nWant '%s'", testName, credential, tc.credential)
+		}
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Bearer abc")
+	r.Header.Set("X-API-Key", "gta_key")
+	if credential := Credential(r); credential != "gta_key" {
+		t.Errorf("Got '%s', want the X-API-Key 'gta_key'", credential)
+	}
+}
+
+func TestGithubAuthenticatorCacheBounded(t *testing.T) {
+	authenticator := NewGithubAuthenticator(nil, nil)
+	authenticator.MaxCachedTokens = 2
+	start := time.Unix(1000, 0)
+	steps := []struct {
+		name   string
+		key    string
+		at     time.Duration
+		cached []string
+	}{
+		{"first", "a", 0, []string{"a"}},
+		{"second", "b", time.Second, []string{"a", "b"}},
+		{"first_expiring_evicted", "c", 2 * time.Second, []string{"b", "c"}},
+		{"replaced_not_evicted", "c", 3 * time.Second, []string{"b", "c"}},
+		{"expired_pruned", "d", DefaultCacheTTL + 2*time.Second, []string{"c", "d"}},
+	}
+	for _, step := range steps {
+
+		authenticator.storePrincipal(step.key, Principal{Login: step.key}, start.Add(step.at))
+
+		for _, key := range []string{"a", "b", "c", "d"} {
+			_, found := authenticator.cache[key]
+			want := false
+			for _, cached := range step.cached {
+				want = want || cached == key
+			}
+			if found != want {
+				t.Errorf("\nTest %s\nGot %s cached %v, want %v", step.name, key, found, want)
+			}
+		}
+	}
+}
+
+func TestGithubAuthenticator(t *testing.T) {
+	calls := 0
+	authenticator := NewGithubAuthenticator(func(ctx context.Context, token string) (string, error) {
+		calls++
+		switch token {
+		case "admin-token":
+			return "Octocat", nil
+		case "user-token":
+			return "joaopmgd", nil
+		}
+		return "", ErrInvalidCredentials
+	}, []string{"octocat"})
+
+	admin, err := authenticator.Authenticate(context.Background(), "admin-token")
+	if err != nil || !reflect.DeepEqual(admin, Principal{Login: "Octocat", Admin: true, Method: MethodGithubToken}) {
+		t.Errorf("Got %+v and error %v, want the admin Octocat", admin, err)
+	}
+	user, err := authenticator.Authenticate(context.Background(), "user-token")
+	if err != nil || user.Admin || !user.CanWrite("JOAOPMGD") || user.CanWrite("octocat") || !admin.CanWrite("joaopmgd") {
+		t.Errorf("Got %+v and error %v, want joaopmgd writing only its own tags", user, err)
+	}
+	if _, err := authenticator.Authenticate(context.Background(), "wrong"); err != ErrInvalidCredentials {
+		t.Errorf("Got error %v, want %v", err, ErrInvalidCredentials)
+	}
+
+	// The valid tokens are cached, the invalid ones are not
+	authenticator.Authenticate(context.Background(), "user-token")
+	authenticator.Authenticate(context.Background(), "wrong")
+	if calls != 4 {
+		t.Errorf("Got %s calls to Github, want 4", strconv.Itoa(calls))
+	}
+}
@@ -2,17 +2,46 @@ package config
 
 import (
 	"bytes"
+	"context"
 	"html/template"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/joaopmgd/github-tag-api/auth"
 	"github.com/joaopmgd/github-tag-api/database"
+	"github.com/joaopmgd/github-tag-api/github"
+	"github.com/joaopmgd/github-tag-api/ratelimit"
+	"github.com/joaopmgd/github-tag-api/tracing"
 )
 
 // Config will setup the Endpoints, the sources that will be requested, Log and Memory
 type Config struct {
 	Endpoints *Endpoint
 	Log       *StandardLogger
-	DB        *database.Gorm
+	DB        database.Store
+	Github    *github.Client
+
+	// MirrorSyncInterval is how often the mirrored starred repos are synced, zero disables the mirror
+	MirrorSyncInterval time.Duration
+	// AutoTagTopics creates a tag for each Github topic of the starred repos
+	AutoTagTopics bool
+
+	// AuthEnabled requires the owner or an admin to change the tags of an user, and hides the private users
+	AuthEnabled bool
+	// Auth finds who is calling the API from the Authorization header, by an API key or a Github token
+	Auth auth.Authenticator
+
+	// RateLimiter limits the requests of each client by route group, nil disables it
+	RateLimiter *ratelimit.Limiter
+
+	// ShutdownTracing flushes the spans that were not exported yet
+	ShutdownTracing func(context.Context) error
+
+	// ctx is the context of the request, set by WithContext
+	ctx context.Context
 }
 
 // Endpoint for the future Requests
@@ -20,25 +49,128 @@ type Endpoint struct {
 	GithubURL          string
 	GithubUserStarred  string
 	GithubHealthStatus string
+	GithubPerPage      int
+	GithubMaxPages     int
+	GithubTokens       []string
+	GithubCacheTTL     time.Duration
+	GithubAuthUser     string
+	GithubOAuthURL     string
+	GithubClientID     string
+	AdminUsers         []string
 }
 
 // GetConfig will setup the config struct for the app to run
 func GetConfig() *Config {
 	log := NewLogger()
-	db, err := database.ConnectToDatabase()
+	db, err := database.NewStore(os.Getenv("DB_DRIVER"))
 	if err != nil {
 		log.DatabaseConnectionError(err.Error())
 		os.Exit(0)
 	}
+	endpoints := &Endpoint{
+		GithubURL:          os.Getenv("GITHUB_PROPERTIES_ENDPOINT"),
+		GithubUserStarred:  os.Getenv("GITHUB_USER_STARRED"),
+		GithubHealthStatus: os.Getenv("GITHUB_HEALTH_STATUS"),
+		GithubPerPage:      getEnvInt("GITHUB_PER_PAGE", github.DefaultPerPage),
+		GithubMaxPages:     getEnvInt("GITHUB_MAX_PAGES", github.DefaultMaxPages),
+		GithubTokens:       getEnvList("GITHUB_TOKEN", "GITHUB_TOKENS"),
+		GithubCacheTTL:     getEnvDuration("GITHUB_CACHE_TTL", github.DefaultCacheTTL),
+		GithubAuthUser:     getEnv("GITHUB_AUTH_USER", "/user"),
+		GithubOAuthURL:     getEnv("GITHUB_OAUTH_URL", "https://github.com"),
+		GithubClientID:     os.Getenv("GITHUB_CLIENT_ID"),
+		AdminUsers:         getEnvList("ADMIN_USERS"),
+	}
+	exporter, err := tracing.NewExporter(context.Background(), os.Getenv("TRACING_EXPORTER"))
+	if err != nil {
+		log.TracingError(err.Error())
+	}
+	githubClient := github.NewClient(endpoints.GithubTokens, endpoints.GithubPerPage, endpoints.GithubMaxPages)
+	githubClient.CacheTTL = endpoints.GithubCacheTTL
+	if API, err := url.Parse(endpoints.GithubURL); err == nil && API.Host != "" {
+		githubClient.APIHost = API.Host
+	}
 	return &Config{
-		Endpoints: &Endpoint{
-			GithubURL:          os.Getenv("GITHUB_PROPERTIES_ENDPOINT"),
-			GithubUserStarred:  os.Getenv("GITHUB_USER_STARRED"),
-			GithubHealthStatus: os.Getenv("GITHUB_HEALTH_STATUS"),
-		},
-		Log: log,
-		DB:  db,
+		Endpoints: endpoints,
+		Log:       log,
+		DB:        db,
+		Github:    githubClient,
+
+		MirrorSyncInterval: getEnvDuration("MIRROR_SYNC_INTERVAL", 0),
+		AutoTagTopics:      os.Getenv("GITHUB_TOPICS_AUTO_TAG") == "true",
+
+		AuthEnabled: os.Getenv("AUTH_ENABLED") == "true",
+		Auth: auth.NewKeyAuthenticator(db, endpoints.AdminUsers,
+			auth.NewGithubAuthenticator(githubLogin(githubClient, endpoints.GithubURL+endpoints.GithubAuthUser), endpoints.AdminUsers)),
+
+		RateLimiter: rateLimiter(log),
+
+		ShutdownTracing: tracing.Install(exporter, getEnv("OTEL_SERVICE_NAME", tracing.DefaultServiceName)),
+	}
+}
+
+// WithContext copies the config for a request, so the database and Github calls are traced under its context
+func (c *Config) WithContext(ctx context.Context) *Config {
+	scoped := *c
+	scoped.ctx = ctx
+	scoped.DB = c.DB.WithContext(ctx)
+	return &scoped
+}
+
+// Context is the context of the request, or the background one outside of the requests
+func (c *Config) Context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
 	}
+	return c.ctx
+}
+
+// rateLimiter creates the limiter from the RATE_LIMIT_{GROUP} variables, as "60/m", nil if no group is limited
+func rateLimiter(log *StandardLogger) *ratelimit.Limiter {
+	limits := make(map[string]ratelimit.Limit)
+	for _, group := range []string{ratelimit.GroupRead, ratelimit.GroupWrite, ratelimit.GroupAuth} {
+		value := os.Getenv("RATE_LIMIT_" + strings.ToUpper(group))
+		if value == "" {
+			continue
+		}
+		limit, err := ratelimit.ParseLimit(value)
+		if err != nil {
+			log.InvalidRateLimit(group, err.Error())
+			continue
+		}
+		limits[group] = limit
+	}
+	if len(limits) == 0 {
+		return nil
+	}
+	return ratelimit.New(limits)
+}
+
+// githubLogin asks Github which login owns a token
+func githubLogin(client *github.Client, URL string) auth.LoginFetcher {
+	return func(ctx context.Context, token string) (string, error) {
+		user, err := client.GetAuthenticatedUser(ctx, URL, token)
+		if err == github.ErrUnauthorized {
+			return "", auth.ErrInvalidCredentials
+		}
+		return user.Login, err
+	}
+}
+
+// getEnv reads an environment variable, returning the fallback if it is not set
+func getEnv(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getEnvInt reads an integer environment variable, returning the fallback if it is not set or invalid
+func getEnvInt(name string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return fallback
+	}
+	return value
 }
 
 // GetStarredReposURL creates the starred repos url
@@ -62,3 +194,25 @@ func (c *Config) GetStarredReposURL(vars map[string]string) (string, error) {
 func (c *Config) GetHealthStatusURL() string {
 	return c.Endpoints.GithubHealthStatus
 }
+
+// getEnvDuration reads a duration environment variable as "30s" or "5m", returning the fallback if it is not set or invalid
+func getEnvDuration(name string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(name))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getEnvList joins the comma separated values of the environment variables, ignoring the empty ones
+func getEnvList(names ...string) []string {
+	var values []string
+	for _, name := range names {
+		for _, value := range strings.Split(os.Getenv(name), ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
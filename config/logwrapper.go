@@ -44,12 +44,24 @@ var (
 	unableToRequest                   = Event{7, "Unable to request data : %s"}
 	restRequestTemplateCreationError  = Event{8, "Error while creating a REST template: %s"}
 	restRequestTemplateExecutionError = Event{9, "Error while executing a REST template: %s"}
-	databaseConnectionError           = Event{10, "Error while trying to connect to the PostgreSQL database: %s"}
+	databaseConnectionError           = Event{10, "Error while trying to connect to the database: %s"}
 	couldNotParseRequestBody          = Event{11, "Could not parse request body : %s"}
 	repoNotFound                      = Event{11, "Repository with id %s was not found"}
-	repoAlreadyTagged                 = Event{12, "Repository already has the tag %s"}
+	repoAlreadyTagged                 = Event{12, "Repository %s already has the tag %s"}
 	stringToInt64Error                = Event{13, "Error while converting the string %s to int64"}
 	pageIsBiggerThanRequestValues     = Event{14, "Requested page is bigger than requested value limit %s, offset %s"}
+	mirrorSynced                      = Event{15, "Starred repos mirror of %s synced with %d repos"}
+	mirrorSyncError                   = Event{16, "Error while syncing the starred repos mirror of %s: %s"}
+	databaseError                     = Event{17, "Error while changing the database: %s"}
+	autoTagsCreated                   = Event{18, "Created %d automatic tags for %s"}
+	invalidAutoTagRule                = Event{19, "Auto tag rule %d of %s is invalid: %s"}
+	authenticationError               = Event{20, "Could not authenticate the request: %s"}
+	accessDenied                      = Event{21, "Access denied to %s for the tags of %s"}
+	apiKeyCreated                     = Event{22, "API key %d created for %s with the scopes %s"}
+	apiKeyRevoked                     = Event{23, "API key %d of %s revoked"}
+	rateLimited                       = Event{24, "Rate limit of the %s routes reached by %s"}
+	invalidRateLimit                  = Event{25, "Rate limit of the %s routes is invalid: %s"}
+	tracingError                      = Event{26, "Tracing is disabled, the exporter could not be created: %s"}
 )
 
 // InitFunction is a standard init function message
@@ -64,6 +76,7 @@ func (l *StandardLogger) EnvVariablesData() {
 		"GITHUB_USER_STARRED":        os.Getenv("GITHUB_USER_STARRED"),
 		"GITHUB_HEALTH_STATUS":       os.Getenv("GITHUB_HEALTH_STATUS"),
 		"HOST":                       os.Getenv("HOST"),
+		"DB_DRIVER":                  os.Getenv("DB_DRIVER"),
 	}
 	if os.Getenv("GITHUB_PROPERTIES_ENDPOINT") == "" ||
 		os.Getenv("GITHUB_USER_STARRED") == "" ||
@@ -131,8 +144,8 @@ func (l *StandardLogger) RepoNotFound(id string) {
 }
 
 // RepoAlreadyTagged logs if the repo already has the tag
-func (l *StandardLogger) RepoAlreadyTagged(tag string) {
-	l.Errorf(repoAlreadyTagged.message, tag)
+func (l *StandardLogger) RepoAlreadyTagged(repo, tag string) {
+	l.Errorf(repoAlreadyTagged.message, repo, tag)
 }
 
 // StringToInt64Error details the error while trying to convert a string to a int number
@@ -144,3 +157,63 @@ func (l *StandardLogger) StringToInt64Error(number string) {
 func (l *StandardLogger) PageIsBiggerThanRequestValues(limit, offset string) {
 	l.Errorf(pageIsBiggerThanRequestValues.message, limit, offset)
 }
+
+// MirrorSynced logs that the mirror of an user was updated
+func (l *StandardLogger) MirrorSynced(user string, repos int) {
+	l.Infof(mirrorSynced.message, user, repos)
+}
+
+// MirrorSyncError details the error while syncing the mirror of an user
+func (l *StandardLogger) MirrorSyncError(user, err string) {
+	l.Errorf(mirrorSyncError.message, user, err)
+}
+
+// DatabaseError details an error returned by the database
+func (l *StandardLogger) DatabaseError(err string) {
+	l.Errorf(databaseError.message, err)
+}
+
+// AutoTagsCreated logs how many automatic tags were created for an user
+func (l *StandardLogger) AutoTagsCreated(user string, tags int) {
+	l.Infof(autoTagsCreated.message, tags, user)
+}
+
+// InvalidAutoTagRule details why a stored rule of an user could not be evaluated
+func (l *StandardLogger) InvalidAutoTagRule(user string, rule uint, err string) {
+	l.Errorf(invalidAutoTagRule.message, rule, user, err)
+}
+
+// AuthenticationError details why the credentials of a request were not accepted
+func (l *StandardLogger) AuthenticationError(err string) {
+	l.Warnf(authenticationError.message, err)
+}
+
+// AccessDenied logs a caller trying to reach the tags of another user
+func (l *StandardLogger) AccessDenied(login, user string) {
+	l.Warnf(accessDenied.message, login, user)
+}
+
+// APIKeyCreated logs a new API key of an user, the key itself is never logged
+func (l *StandardLogger) APIKeyCreated(user string, key uint, scopes string) {
+	l.Infof(apiKeyCreated.message, key, user, scopes)
+}
+
+// APIKeyRevoked logs an API key of an user being revoked
+func (l *StandardLogger) APIKeyRevoked(user string, key uint) {
+	l.Infof(apiKeyRevoked.message, key, user)
+}
+
+// RateLimited logs a client that reached the rate limit of a route group
+func (l *StandardLogger) RateLimited(group, client string) {
+	l.Warnf(rateLimited.message, group, client)
+}
+
+// InvalidRateLimit details why the rate limit of a route group was ignored
+func (l *StandardLogger) InvalidRateLimit(group, err string) {
+	l.Errorf(invalidRateLimit.message, group, err)
+}
+
+// TracingError details why the spans are not exported
+func (l *StandardLogger) TracingError(err string) {
+	l.Errorf(tracingError.message, err)
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// APIKey is a key issued to an user, only the hash of the key is stored.
+// A revoked key is soft deleted, and the scopes are kept separated by commas.
+type APIKey struct {
+	gorm.Model
+
+	UserID     string `gorm:"index"`
+	Name       string
+	Prefix     string
+	Hash       string `gorm:"unique_index"`
+	Scopes     string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+}
+
+// InsertAPIKey inserts a new key, setting its ID
+func (db *Gorm) InsertAPIKey(value *APIKey) error {
+	return db.Conn.Create(value).Error
+}
+
+// RevokeAPIKey deletes a key, false if the user has no such key
+func (db *Gorm) RevokeAPIKey(userID string, id uint) bool {
+	return db.Conn.Where("id = ? AND user_id = ?", id, userID).Delete(APIKey{}).RowsAffected > 0
+}
+
+// GetAPIKeys recovers every key of an user that was not revoked, in the order they were created
+func (db *Gorm) GetAPIKeys(userID string) []APIKey {
+	var keys []APIKey
+	db.Conn.Where("user_id = ?", userID).Order("id").Find(&keys)
+	return keys
+}
+
+// GetAPIKeyByHash recovers the key with the hash, false if it does not exist or was revoked
+func (db *Gorm) GetAPIKeyByHash(hash string) (APIKey, bool) {
+	var key APIKey
+	if db.Conn.Where("hash = ?", hash).First(&key).RecordNotFound() {
+		return APIKey{}, false
+	}
+	return key, true
+}
+
+// TouchAPIKey records when a key was last used
+func (db *Gorm) TouchAPIKey(id uint, usedAt time.Time) error {
+	return db.Conn.Model(&APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", usedAt).Error
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// AutoTagRule is a rule of an user that tags the matching starred repos, the conditions are kept as JSON
+type AutoTagRule struct {
+	gorm.Model
+
+	UserID     string `gorm:"index"`
+	Name       string
+	TagName    string
+	Conditions string `gorm:"type:text"`
+}
+
+// InsertAutoTagRule inserts a new rule, setting its ID
+func (db *Gorm) InsertAutoTagRule(value *AutoTagRule) error {
+	return db.Conn.Create(value).Error
+}
+
+// UpdateAutoTagRule replaces the name, tag and conditions of a rule, false if the user has no such rule
+func (db *Gorm) UpdateAutoTagRule(value AutoTagRule) (bool, error) {
+	result := db.Conn.Model(&AutoTagRule{}).
+		Where("id = ? AND user_id = ?", value.ID, value.UserID).
+		Updates(map[string]interface{}{"name": value.Name, "tag_name": value.TagName, "conditions": value.Conditions})
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteAutoTagRule deletes a rule, false if the user has no such rule
+func (db *Gorm) DeleteAutoTagRule(userID string, id uint) bool {
+	return db.Conn.Where("id = ? AND user_id = ?", id, userID).Delete(AutoTagRule{}).RowsAffected > 0
+}
+
+// GetAutoTagRule recovers a rule of an user, false if it does not exist
+func (db *Gorm) GetAutoTagRule(userID string, id uint) (AutoTagRule, bool) {
+	var rule AutoTagRule
+	if db.Conn.Where("id = ? AND user_id = ?", id, userID).First(&rule).RecordNotFound() {
+		return AutoTagRule{}, false
+	}
+	return rule, true
+}
+
+// GetAutoTagRules recovers every rule of an user, in the order they were created
+func (db *Gorm) GetAutoTagRules(userID string) []AutoTagRule {
+	var rules []AutoTagRule
+	db.Conn.Where("user_id = ?", userID).Order("id").Find(&rules)
+	return rules
+}
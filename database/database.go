@@ -21,12 +21,12 @@ func ConnectToDatabase() (*Gorm, error) {
 	if err != nil {
 		return nil, err
 	}
-	if !db.HasTable(&RepoTag{}) {
-		db.CreateTable(&RepoTag{})
-	}
-	if !db.HasTable(&LanguageTag{}) {
-		db.CreateTable(&LanguageTag{})
+	// AutoMigrate creates the missing tables and adds the new columns to the existing ones
+	if err := db.AutoMigrate(&RepoTag{}, &LanguageTag{}, &StarredRepo{}, &MirrorUser{}, &AutoTagRule{}, &UserSettings{}, &APIKey{}).Error; err != nil {
+		return nil, err
 	}
+	registerMetricsCallbacks(db)
+	registerTracingCallbacks(db)
 	return &Gorm{Conn: db}, nil
 }
 
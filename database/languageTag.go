@@ -8,6 +8,8 @@ import (
 type LanguageTag struct {
 	gorm.Model
 
+	UserID   string
+	RepoID   int64
 	Language string
 	TagName  string
 }
@@ -17,17 +19,28 @@ func (db *Gorm) InsertLanguageTagsValue(value LanguageTag) {
 	db.Conn.Create(&value)
 }
 
-// GetRecommendationTagByLanguage returns an array of string ordered by usage based on the language
-func (db *Gorm) GetRecommendationTagByLanguage(language string) []string {
-	var tags []LanguageTag
+// TagCount counts how many distinct repos and users applied a tag
+type TagCount struct {
+	TagName string
+	Repos   int
+	Users   int
+}
+
+// legacyRepoCount counts the distinct repos of the tags, the rows saved before the repo_id column have no repo and each one counts as its own repo
+const legacyRepoCount = "count(distinct coalesce(nullif(repo_id, 0)::text, 'legacy-' || id::text))"
+
+// GetRecommendationTagByLanguage returns the tags used with the language ordered by usage, the most applied ones first.
+// Only the tags applied to at least minCount repos are returned, up to limit of them.
+func (db *Gorm) GetRecommendationTagByLanguage(language string, limit, minCount int) []TagCount {
+	query := db.Conn.Model(&LanguageTag{}).Select("tag_name, " + legacyRepoCount + " as repos, count(distinct user_id) as users")
 	if language != "" {
-		db.Conn.Select("tag_name").Where("language = ?", language).Group("tag_name").Limit(10).Find(&tags)
-	} else {
-		db.Conn.Select("tag_name").Group("tag_name").Limit(10).Find(&tags)
-	}
-	var mostUsedTags []string
-	for _, tag := range tags {
-		mostUsedTags = append(mostUsedTags, tag.TagName)
+		query = query.Where("language = ?", language)
 	}
+	var mostUsedTags []TagCount
+	query.Group("tag_name").
+		Having(legacyRepoCount+" >= ?", minCount).
+		Order("repos desc, users desc, tag_name").
+		Limit(limit).
+		Scan(&mostUsedTags)
 	return mostUsedTags
 }
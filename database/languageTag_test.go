@@ -0,0 +1,55 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+)
+
+func TestGetRecommendationTagByLanguageLegacyRows(t *testing.T) {
+	db := NewMemory()
+	// The tags saved before the repo and user columns have neither of them
+	for _, languageTag := range []LanguageTag{
+		{Language: "Go", TagName: "cli"},
+		{Language: "Go", TagName: "cli"},
+		{Language: "Go", TagName: "router"},
+		{UserID: "a", RepoID: 1, Language: "Go", TagName: "router"},
+	} {
+		db.InsertLanguageTagsValue(languageTag)
+	}
+
+	recommended := db.GetRecommendationTagByLanguage("Go", 10, 1)
+
+	if fmt.Sprint(recommended) != "[{router 2 1} {cli 2 0}]" {
+		t.Errorf("Got language tags %v\nWant [{router 2 1} {cli 2 0}]", recommended)
+	}
+}
+
+func TestGormGetRecommendationTagByLanguage(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	conn, err := gorm.Open("postgres", sqlDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repos := "count(distinct coalesce(nullif(repo_id, 0)::text, 'legacy-' || id::text))"
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT tag_name, `+repos+` as repos, count(distinct user_id) as users FROM "language_tags"`)+
+		`.*`+regexp.QuoteMeta(`GROUP BY tag_name HAVING (`+repos+` >= $2) ORDER BY repos desc, users desc, tag_name LIMIT 10`)).
+		WithArgs("Go", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"tag_name", "repos", "users"}).AddRow("router", 2, 1).AddRow("cli", 2, 0))
+
+	recommended := (&Gorm{Conn: conn}).GetRecommendationTagByLanguage("Go", 10, 1)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(recommended) != "[{router 2 1} {cli 2 0}]" {
+		t.Errorf("Got language tags %v\nWant [{router 2 1} {cli 2 0}]", recommended)
+	}
+}
@@ -0,0 +1,594 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Memory stores the tags in memory, so the API can run without a PostgreSQL database
+type Memory struct {
+	mu           sync.RWMutex
+	lastID       uint
+	repoTags     []RepoTag
+	languageTags []LanguageTag
+	starredRepos map[string][]StarredRepo
+	mirrorUsers  map[string]MirrorUser
+	rules        []AutoTagRule
+	userSettings map[string]UserSettings
+	apiKeys      []APIKey
+}
+
+// NewMemory creates an empty in-memory TagStore
+func NewMemory() *Memory {
+	return &Memory{
+		starredRepos: make(map[string][]StarredRepo),
+		mirrorUsers:  make(map[string]MirrorUser),
+		userSettings: make(map[string]UserSettings),
+	}
+}
+
+// Ping checks database connection, memory is always available
+func (db *Memory) Ping() error {
+	return nil
+}
+
+// WithContext returns the same store, the memory has no statements to trace
+func (db *Memory) WithContext(ctx context.Context) Store {
+	return db
+}
+
+// nextID emulates the auto increment primary key, the lock must be held by the caller
+func (db *Memory) nextID() uint {
+	db.lastID++
+	return db.lastID
+}
+
+// InsertRepoTagsValue inserts in memory a new repo tag
+func (db *Memory) InsertRepoTagsValue(value RepoTag) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.insertRepoTag(value)
+}
+
+func (db *Memory) insertRepoTag(value RepoTag) {
+	now := time.Now()
+	value.ID = db.nextID()
+	value.CreatedAt = now
+	value.UpdatedAt = now
+	db.repoTags = append(db.repoTags, value)
+}
+
+// DeleteRepoTagsValue soft deletes the value, just like gorm does, returning how many rows were deleted
+func (db *Memory) DeleteRepoTagsValue(value RepoTag) int {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.deleteRepoTag(value)
+}
+
+func (db *Memory) deleteRepoTag(value RepoTag) int {
+	now := time.Now()
+	deleted := 0
+	for i, repoTag := range db.repoTags {
+		if repoTag.DeletedAt == nil &&
+			repoTag.UserID == value.UserID &&
+			repoTag.RepoID == value.RepoID &&
+			repoTag.TagName == value.TagName {
+			db.repoTags[i].DeletedAt = &now
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// deleteLanguageTags removes the language tags the user applied to the repo with the tag
+func (db *Memory) deleteLanguageTags(userID string, repoID int64, tagName string) {
+	now := time.Now()
+	for i, languageTag := range db.languageTags {
+		if languageTag.DeletedAt == nil &&
+			languageTag.UserID == userID &&
+			languageTag.RepoID == repoID &&
+			languageTag.TagName == tagName {
+			db.languageTags[i].DeletedAt = &now
+		}
+	}
+}
+
+// GetAllRepoTagsMap recovers all repo tags for and user id
+func (db *Memory) GetAllRepoTagsMap(userID string) map[int64][]string {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	repoTagsMap := make(map[int64][]string)
+	for _, repoTag := range db.repoTags {
+		if repoTag.DeletedAt == nil && repoTag.UserID == userID {
+			repoTagsMap[repoTag.RepoID] = append(repoTagsMap[repoTag.RepoID], repoTag.TagName)
+		}
+	}
+	return repoTagsMap
+}
+
+// GetAllRepoTagsByRepoID recovers all repo tags for an repo id and user id
+func (db *Memory) GetAllRepoTagsByRepoID(userID string, repoID int64) []RepoTag {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	var repoTags []RepoTag
+	for _, repoTag := range db.repoTags {
+		if repoTag.DeletedAt == nil && repoTag.UserID == userID && repoTag.RepoID == repoID {
+			repoTags = append(repoTags, repoTag)
+		}
+	}
+	return repoTags
+}
+
+// GetAllRepoTags recovers every repo tag of an user
+func (db *Memory) GetAllRepoTags(userID string) []RepoTag {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	var repoTags []RepoTag
+	for _, repoTag := range db.repoTags {
+		if repoTag.DeletedAt == nil && repoTag.UserID == userID {
+			repoTags = append(repoTags, repoTag)
+		}
+	}
+	return repoTags
+}
+
+// InsertAutoRepoTags inserts the tags created automatically, returning how many were inserted.
+// A tag is skipped when the repo has or ever had it, so the automatic tags removed by the user are not created again.
+// Each tag is also added to the language tags, with the language of its repo.
+func (db *Memory) InsertAutoRepoTags(userID string, values []RepoTag, languages map[int64]string) (int, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var existing []RepoTag
+	for _, repoTag := range db.repoTags {
+		if repoTag.UserID == userID {
+			existing = append(existing, repoTag)
+		}
+	}
+	selected := newAutoRepoTags(userID, existing, values)
+	for _, value := range selected {
+		db.insertRepoTag(value)
+		db.insertLanguageTag(LanguageTag{UserID: userID, RepoID: value.RepoID, Language: languages[value.RepoID], TagName: value.TagName})
+	}
+	return len(selected), nil
+}
+
+// GetTagUsage recovers every distinct tag of an user with its repo count and when it was first and last used
+func (db *Memory) GetTagUsage(userID string) []TagUsage {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	var usage []TagUsage
+	index := make(map[string]int)
+	repos := make(map[string]map[int64]bool)
+	for _, repoTag := range db.repoTags {
+		if repoTag.DeletedAt != nil || repoTag.UserID != userID {
+			continue
+		}
+		i, found := index[repoTag.TagName]
+		if !found {
+			i = len(usage)
+			index[repoTag.TagName] = i
+			repos[repoTag.TagName] = make(map[int64]bool)
+			usage = append(usage, TagUsage{TagName: repoTag.TagName, FirstUsed: repoTag.CreatedAt})
+		}
+		repos[repoTag.TagName][repoTag.RepoID] = true
+		usage[i].RepoCount = len(repos[repoTag.TagName])
+		if repoTag.CreatedAt.Before(usage[i].FirstUsed) {
+			usage[i].FirstUsed = repoTag.CreatedAt
+		}
+		if repoTag.CreatedAt.After(usage[i].LastUsed) {
+			usage[i].LastUsed = repoTag.CreatedAt
+		}
+	}
+	return usage
+}
+
+// MergeTags renames the from tags of an user to the to tag, returning how many repos changed.
+// Repos that already have the to tag just lose the from tags, and the language tags follow the repo tags.
+func (db *Memory) MergeTags(userID string, from []string, to string) (int, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	now := time.Now()
+	merged := make(map[string]bool)
+	for _, tag := range from {
+		merged[tag] = tag != to
+	}
+	hasTag := make(map[int64]bool)
+	for _, repoTag := range db.repoTags {
+		if repoTag.DeletedAt == nil && repoTag.UserID == userID && repoTag.TagName == to {
+			hasTag[repoTag.RepoID] = true
+		}
+	}
+	changedRepos := make(map[int64]bool)
+	for i, repoTag := range db.repoTags {
+		if repoTag.DeletedAt != nil || repoTag.UserID != userID || !merged[repoTag.TagName] {
+			continue
+		}
+		changedRepos[repoTag.RepoID] = true
+		duplicated := hasTag[repoTag.RepoID]
+		for j, languageTag := range db.languageTags {
+			if languageTag.DeletedAt == nil && languageTag.UserID == userID &&
+				languageTag.RepoID == repoTag.RepoID && languageTag.TagName == repoTag.TagName {
+				if duplicated {
+					db.languageTags[j].DeletedAt = &now
+				} else {
+					db.languageTags[j].TagName = to
+					db.languageTags[j].UpdatedAt = now
+				}
+			}
+		}
+		if duplicated {
+			db.repoTags[i].DeletedAt = &now
+		} else {
+			hasTag[repoTag.RepoID] = true
+			db.repoTags[i].TagName = to
+			db.repoTags[i].UpdatedAt = now
+		}
+	}
+	return len(changedRepos), nil
+}
+
+// BulkUpdateRepoTags applies every change at once, the language tags are added and removed with the repo tags
+func (db *Memory) BulkUpdateRepoTags(userID string, changes []RepoTagChange) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, change := range changes {
+		for _, tag := range change.Remove {
+			db.deleteRepoTag(RepoTag{UserID: userID, RepoID: change.RepoID, TagName: tag})
+			db.deleteLanguageTags(userID, change.RepoID, tag)
+		}
+		for _, tag := range change.Add {
+			db.insertRepoTag(RepoTag{UserID: userID, RepoID: change.RepoID, TagName: tag})
+			db.insertLanguageTag(LanguageTag{UserID: userID, RepoID: change.RepoID, Language: change.Language, TagName: tag})
+		}
+	}
+	return nil
+}
+
+// GetCoOccurringTags counts the tags applied together with the tag on the same repo by the same user, across every user
+func (db *Memory) GetCoOccurringTags(tag string, limit int) []TagCount {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	type userRepo struct {
+		userID string
+		repoID int64
+	}
+	tagged := make(map[userRepo]bool)
+	for _, repoTag := range db.repoTags {
+		if repoTag.DeletedAt == nil && repoTag.TagName == tag {
+			tagged[userRepo{repoTag.UserID, repoTag.RepoID}] = true
+		}
+	}
+	var others []RepoTag
+	for _, repoTag := range db.repoTags {
+		if repoTag.DeletedAt == nil && repoTag.TagName != tag && tagged[userRepo{repoTag.UserID, repoTag.RepoID}] {
+			others = append(others, repoTag)
+		}
+	}
+	return countRepoTags(others, limit)
+}
+
+// GetRepoTagsByOtherUsers counts the tags applied to the repo by every other user
+func (db *Memory) GetRepoTagsByOtherUsers(userID string, repoID int64, limit int) []TagCount {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	var others []RepoTag
+	for _, repoTag := range db.repoTags {
+		if repoTag.DeletedAt == nil && repoTag.RepoID == repoID && repoTag.UserID != userID {
+			others = append(others, repoTag)
+		}
+	}
+	return countRepoTags(others, limit)
+}
+
+// countRepoTags counts the distinct repos and users of each tag, the most used first
+func countRepoTags(repoTags []RepoTag, limit int) []TagCount {
+	repos := make(map[string]map[int64]bool)
+	users := make(map[string]map[string]bool)
+	for _, repoTag := range repoTags {
+		if repos[repoTag.TagName] == nil {
+			repos[repoTag.TagName] = make(map[int64]bool)
+			users[repoTag.TagName] = make(map[string]bool)
+		}
+		repos[repoTag.TagName][repoTag.RepoID] = true
+		users[repoTag.TagName][repoTag.UserID] = true
+	}
+	var counts []TagCount
+	for tagName := range repos {
+		counts = append(counts, TagCount{TagName: tagName, Repos: len(repos[tagName]), Users: len(users[tagName])})
+	}
+	sortTagCounts(counts)
+	if len(counts) > limit {
+		counts = counts[:limit]
+	}
+	return counts
+}
+
+// InsertLanguageTagsValue inserts in memory a new language tag
+func (db *Memory) InsertLanguageTagsValue(value LanguageTag) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.insertLanguageTag(value)
+}
+
+func (db *Memory) insertLanguageTag(value LanguageTag) {
+	now := time.Now()
+	value.ID = db.nextID()
+	value.CreatedAt = now
+	value.UpdatedAt = now
+	db.languageTags = append(db.languageTags, value)
+}
+
+// GetRecommendationTagByLanguage returns the tags used with the language ordered by usage, the most applied ones first.
+// Only the tags applied to at least minCount repos are returned, up to limit of them.
+func (db *Memory) GetRecommendationTagByLanguage(language string, limit, minCount int) []TagCount {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	repos := make(map[string]map[string]bool)
+	users := make(map[string]map[string]bool)
+	for _, tag := range db.languageTags {
+		if tag.DeletedAt != nil || (language != "" && tag.Language != language) {
+			continue
+		}
+		if repos[tag.TagName] == nil {
+			repos[tag.TagName] = make(map[string]bool)
+			users[tag.TagName] = make(map[string]bool)
+		}
+		// A tag saved without its repo counts as its own repo, as the legacy rows in the database
+		repo := "legacy-" + strconv.FormatUint(uint64(tag.ID), 10)
+		if tag.RepoID != 0 {
+			repo = strconv.FormatInt(tag.RepoID, 10)
+		}
+		repos[tag.TagName][repo] = true
+		if tag.UserID != "" {
+			users[tag.TagName][tag.UserID] = true
+		}
+	}
+	var mostUsedTags []TagCount
+	for tagName := range repos {
+		if len(repos[tagName]) >= minCount {
+			mostUsedTags = append(mostUsedTags, TagCount{TagName: tagName, Repos: len(repos[tagName]), Users: len(users[tagName])})
+		}
+	}
+	sortTagCounts(mostUsedTags)
+	if len(mostUsedTags) > limit {
+		mostUsedTags = mostUsedTags[:limit]
+	}
+	return mostUsedTags
+}
+
+// sortTagCounts orders the tags by repos and users, the most used first, and then by name
+func sortTagCounts(counts []TagCount) {
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Repos != counts[j].Repos {
+			return counts[i].Repos > counts[j].Repos
+		}
+		if counts[i].Users != counts[j].Users {
+			return counts[i].Users > counts[j].Users
+		}
+		return counts[i].TagName < counts[j].TagName
+	})
+}
+
+// SaveStarredRepos replaces the user starred repos and registers the sync time
+func (db *Memory) SaveStarredRepos(userID string, repos []StarredRepo, syncedAt time.Time) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	now := time.Now()
+	mirrored := make([]StarredRepo, len(repos))
+	for i, repo := range repos {
+		repo.ID = db.nextID()
+		repo.CreatedAt = now
+		repo.UpdatedAt = now
+		repo.UserID = userID
+		mirrored[i] = repo
+	}
+	db.starredRepos[userID] = mirrored
+	user, found := db.mirrorUsers[userID]
+	if !found {
+		user = MirrorUser{UserID: userID}
+		user.ID = db.nextID()
+		user.CreatedAt = now
+	}
+	user.UpdatedAt = now
+	user.SyncedAt = syncedAt
+	user.SyncError = ""
+	db.mirrorUsers[userID] = user
+	return nil
+}
+
+// SaveMirrorError registers why the last sync of an already mirrored user failed
+func (db *Memory) SaveMirrorError(userID string, reason string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if user, found := db.mirrorUsers[userID]; found {
+		user.SyncError = reason
+		db.mirrorUsers[userID] = user
+	}
+	return nil
+}
+
+// GetStarredRepos recovers the mirrored starred repos of an user, false if the user was never synced
+func (db *Memory) GetStarredRepos(userID string) ([]StarredRepo, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	if _, found := db.mirrorUsers[userID]; !found {
+		return nil, false
+	}
+	return append([]StarredRepo{}, db.starredRepos[userID]...), true
+}
+
+// GetMirrorUsers recovers every mirrored user
+func (db *Memory) GetMirrorUsers() []MirrorUser {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	users := []MirrorUser{}
+	for _, user := range db.mirrorUsers {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].UserID < users[j].UserID })
+	return users
+}
+
+// InsertAutoTagRule inserts a new rule, setting its ID
+func (db *Memory) InsertAutoTagRule(value *AutoTagRule) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	now := time.Now()
+	value.ID = db.nextID()
+	value.CreatedAt = now
+	value.UpdatedAt = now
+	db.rules = append(db.rules, *value)
+	return nil
+}
+
+// UpdateAutoTagRule replaces the name, tag and conditions of a rule, false if the user has no such rule
+func (db *Memory) UpdateAutoTagRule(value AutoTagRule) (bool, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for i, rule := range db.rules {
+		if rule.DeletedAt == nil && rule.ID == value.ID && rule.UserID == value.UserID {
+			db.rules[i].Name = value.Name
+			db.rules[i].TagName = value.TagName
+			db.rules[i].Conditions = value.Conditions
+			db.rules[i].UpdatedAt = time.Now()
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// DeleteAutoTagRule soft deletes a rule, false if the user has no such rule
+func (db *Memory) DeleteAutoTagRule(userID string, id uint) bool {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for i, rule := range db.rules {
+		if rule.DeletedAt == nil && rule.ID == id && rule.UserID == userID {
+			now := time.Now()
+			db.rules[i].DeletedAt = &now
+			return true
+		}
+	}
+	return false
+}
+
+// GetAutoTagRule recovers a rule of an user, false if it does not exist
+func (db *Memory) GetAutoTagRule(userID string, id uint) (AutoTagRule, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	for _, rule := range db.rules {
+		if rule.DeletedAt == nil && rule.ID == id && rule.UserID == userID {
+			return rule, true
+		}
+	}
+	return AutoTagRule{}, false
+}
+
+// GetAutoTagRules recovers every rule of an user, in the order they were created
+func (db *Memory) GetAutoTagRules(userID string) []AutoTagRule {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	var rules []AutoTagRule
+	for _, rule := range db.rules {
+		if rule.DeletedAt == nil && rule.UserID == userID {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// GetUserSettings recovers the settings of an user, false if they were never saved
+func (db *Memory) GetUserSettings(userID string) (UserSettings, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	settings, found := db.userSettings[userID]
+	return settings, found
+}
+
+// SaveUserSettings creates or replaces the settings of an user
+func (db *Memory) SaveUserSettings(value UserSettings) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	now := time.Now()
+	if settings, found := db.userSettings[value.UserID]; found {
+		value.ID = settings.ID
+		value.CreatedAt = settings.CreatedAt
+	} else {
+		value.ID = db.nextID()
+		value.CreatedAt = now
+	}
+	value.UpdatedAt = now
+	db.userSettings[value.UserID] = value
+	return nil
+}
+
+// InsertAPIKey inserts a new key, setting its ID
+func (db *Memory) InsertAPIKey(value *APIKey) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, key := range db.apiKeys {
+		if key.Hash == value.Hash {
+			return errors.New("There is already a key with the same hash")
+		}
+	}
+	now := time.Now()
+	value.ID = db.nextID()
+	value.CreatedAt = now
+	value.UpdatedAt = now
+	db.apiKeys = append(db.apiKeys, *value)
+	return nil
+}
+
+// RevokeAPIKey soft deletes a key, false if the user has no such key
+func (db *Memory) RevokeAPIKey(userID string, id uint) bool {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for i, key := range db.apiKeys {
+		if key.DeletedAt == nil && key.ID == id && key.UserID == userID {
+			now := time.Now()
+			db.apiKeys[i].DeletedAt = &now
+			return true
+		}
+	}
+	return false
+}
+
+// GetAPIKeys recovers every key of an user that was not revoked, in the order they were created
+func (db *Memory) GetAPIKeys(userID string) []APIKey {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	var keys []APIKey
+	for _, key := range db.apiKeys {
+		if key.DeletedAt == nil && key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// GetAPIKeyByHash recovers the key with the hash, false if it does not exist or was revoked
+func (db *Memory) GetAPIKeyByHash(hash string) (APIKey, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	for _, key := range db.apiKeys {
+		if key.DeletedAt == nil && key.Hash == hash {
+			return key, true
+		}
+	}
+	return APIKey{}, false
+}
+
+// TouchAPIKey records when a key was last used
+func (db *Memory) TouchAPIKey(id uint, usedAt time.Time) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for i, key := range db.apiKeys {
+		if key.ID == id {
+			db.apiKeys[i].LastUsedAt = &usedAt
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/joaopmgd/github-tag-api/metrics"
+)
+
+// metricsStartKey keeps in the scope when the statement started
+const metricsStartKey = "metrics:start"
+
+// registerMetricsCallbacks times the statement of every create, update, delete and query sent by the connection
+func registerMetricsCallbacks(db *gorm.DB) {
+	callback := db.Callback()
+	// Each registration needs its own processor, so the map has the functions that create them
+	for operation, processor := range map[string]func() *gorm.CallbackProcessor{
+		"create":    callback.Create,
+		"update":    callback.Update,
+		"delete":    callback.Delete,
+		"query":     callback.Query,
+		"row_query": callback.RowQuery,
+	} {
+		operation := operation
+		processor().Before("gorm:"+operation).Register("metrics:before_"+operation, func(scope *gorm.Scope) {
+			scope.InstanceSet(metricsStartKey, time.Now())
+		})
+		processor().After("gorm:"+operation).Register("metrics:after_"+operation, func(scope *gorm.Scope) {
+			if start, ok := scope.InstanceGet(metricsStartKey); ok {
+				metrics.ObserveDBQuery(operation, scope.TableName(), time.Since(start.(time.Time)))
+			}
+		})
+	}
+}
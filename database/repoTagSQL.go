@@ -1,9 +1,18 @@
 package database
 
 import (
+	"time"
+
 	"github.com/jinzhu/gorm"
 )
 
+// Sources of the repo tags, the tags created by the user itself have no source
+const (
+	TagSourceUser        = ""
+	TagSourceGithubTopic = "github_topic"
+	TagSourceRule        = "rule"
+)
+
 // RepoTag are the tags of some repo
 type RepoTag struct {
 	gorm.Model
@@ -11,6 +20,24 @@ type RepoTag struct {
 	UserID  string
 	RepoID  int64
 	TagName string
+	Source  string
+	RuleID  uint
+}
+
+// TagUsage counts how many repos of an user have the tag
+type TagUsage struct {
+	TagName   string
+	RepoCount int
+	FirstUsed time.Time
+	LastUsed  time.Time
+}
+
+// RepoTagChange has the tags to add and remove from a repo
+type RepoTagChange struct {
+	RepoID   int64
+	Language string
+	Add      []string
+	Remove   []string
 }
 
 // InsertRepoTagsValue inserts in the database a new repo tag
@@ -18,9 +45,9 @@ func (db *Gorm) InsertRepoTagsValue(value RepoTag) {
 	db.Conn.Create(&value)
 }
 
-// DeleteRepoTagsValue deletes value from database
-func (db *Gorm) DeleteRepoTagsValue(value RepoTag) {
-	db.Conn.Where("user_id = ? AND repo_id = ? AND tag_name = ?", value.UserID, value.RepoID, value.TagName).Delete(RepoTag{})
+// DeleteRepoTagsValue deletes value from database, returning how many rows were deleted
+func (db *Gorm) DeleteRepoTagsValue(value RepoTag) int {
+	return int(db.Conn.Where("user_id = ? AND repo_id = ? AND tag_name = ?", value.UserID, value.RepoID, value.TagName).Delete(RepoTag{}).RowsAffected)
 }
 
 // GetAllRepoTagsMap recovers all repo tags for and user id
@@ -40,3 +67,166 @@ func (db *Gorm) GetAllRepoTagsByRepoID(userID string, repoID int64) []RepoTag {
 	db.Conn.Where("user_id = ? AND repo_id = ?", userID, repoID).Find(&repoTags)
 	return repoTags
 }
+
+// GetTagUsage recovers every distinct tag of an user with its repo count and when it was first and last used
+func (db *Gorm) GetTagUsage(userID string) []TagUsage {
+	var usage []TagUsage
+	db.Conn.Model(&RepoTag{}).
+		Select("tag_name, count(distinct repo_id) as repo_count, min(created_at) as first_used, max(created_at) as last_used").
+		Where("user_id = ?", userID).
+		Group("tag_name").
+		Scan(&usage)
+	return usage
+}
+
+// MergeTags renames the from tags of an user to the to tag in a single transaction, returning how many repos changed.
+// Repos that already have the to tag just lose the from tags, and the language tags follow the repo tags.
+func (db *Gorm) MergeTags(userID string, from []string, to string) (int, error) {
+	tx := db.Conn.Begin()
+	var repoTags, tagged []RepoTag
+	if err := tx.Where("user_id = ? AND tag_name IN (?)", userID, from).Find(&repoTags).Error; err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if err := tx.Where("user_id = ? AND tag_name = ?", userID, to).Find(&tagged).Error; err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	hasTag := make(map[int64]bool)
+	for _, repoTag := range tagged {
+		hasTag[repoTag.RepoID] = true
+	}
+	changedRepos := make(map[int64]bool)
+	for _, repoTag := range repoTags {
+		if repoTag.TagName == to {
+			continue
+		}
+		changedRepos[repoTag.RepoID] = true
+		languageTags := tx.Model(&LanguageTag{}).Where("user_id = ? AND repo_id = ? AND tag_name = ?", userID, repoTag.RepoID, repoTag.TagName)
+		var err error
+		if hasTag[repoTag.RepoID] {
+			if err = tx.Delete(&repoTag).Error; err == nil {
+				err = languageTags.Delete(&LanguageTag{}).Error
+			}
+		} else {
+			hasTag[repoTag.RepoID] = true
+			if err = tx.Model(&repoTag).Update("tag_name", to).Error; err == nil {
+				err = languageTags.Update("tag_name", to).Error
+			}
+		}
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+	return len(changedRepos), tx.Commit().Error
+}
+
+// BulkUpdateRepoTags applies every change in a single transaction, the language tags are added and removed with the repo tags
+func (db *Gorm) BulkUpdateRepoTags(userID string, changes []RepoTagChange) error {
+	tx := db.Conn.Begin()
+	for _, change := range changes {
+		for _, tag := range change.Remove {
+			err := tx.Where("user_id = ? AND repo_id = ? AND tag_name = ?", userID, change.RepoID, tag).Delete(RepoTag{}).Error
+			if err == nil {
+				err = tx.Where("user_id = ? AND repo_id = ? AND tag_name = ?", userID, change.RepoID, tag).Delete(LanguageTag{}).Error
+			}
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		for _, tag := range change.Add {
+			if err := tx.Create(&RepoTag{UserID: userID, RepoID: change.RepoID, TagName: tag}).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+			if err := tx.Create(&LanguageTag{UserID: userID, RepoID: change.RepoID, Language: change.Language, TagName: tag}).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+	return tx.Commit().Error
+}
+
+// GetCoOccurringTags counts the tags applied together with the tag on the same repo by the same user, across every user
+func (db *Gorm) GetCoOccurringTags(tag string, limit int) []TagCount {
+	var counts []TagCount
+	db.Conn.Raw(`SELECT other.tag_name, count(distinct other.repo_id) AS repos, count(distinct other.user_id) AS users
+		FROM repo_tags AS base
+		JOIN repo_tags AS other ON other.user_id = base.user_id AND other.repo_id = base.repo_id
+		WHERE base.tag_name = ? AND other.tag_name <> base.tag_name
+		AND base.deleted_at IS NULL AND other.deleted_at IS NULL
+		GROUP BY other.tag_name
+		ORDER BY repos DESC, users DESC, other.tag_name
+		LIMIT ?`, tag, limit).Scan(&counts)
+	return counts
+}
+
+// GetRepoTagsByOtherUsers counts the tags applied to the repo by every other user
+func (db *Gorm) GetRepoTagsByOtherUsers(userID string, repoID int64, limit int) []TagCount {
+	var counts []TagCount
+	db.Conn.Model(&RepoTag{}).
+		Select("tag_name, count(distinct repo_id) as repos, count(distinct user_id) as users").
+		Where("repo_id = ? AND user_id <> ?", repoID, userID).
+		Group("tag_name").
+		Order("users desc, tag_name").
+		Limit(limit).
+		Scan(&counts)
+	return counts
+}
+
+// GetAllRepoTags recovers every repo tag of an user
+func (db *Gorm) GetAllRepoTags(userID string) []RepoTag {
+	var repoTags []RepoTag
+	db.Conn.Where("user_id = ?", userID).Order("id").Find(&repoTags)
+	return repoTags
+}
+
+// InsertAutoRepoTags inserts the tags created automatically, returning how many were inserted.
+// A tag is skipped when the repo has or ever had it, so the automatic tags removed by the user are not created again.
+// Each tag is also added to the language tags, with the language of its repo.
+func (db *Gorm) InsertAutoRepoTags(userID string, values []RepoTag, languages map[int64]string) (int, error) {
+	var existing []RepoTag
+	if err := db.Conn.Unscoped().Select("repo_id, tag_name").Where("user_id = ?", userID).Find(&existing).Error; err != nil {
+		return 0, err
+	}
+	tx := db.Conn.Begin()
+	inserted := 0
+	for _, value := range newAutoRepoTags(userID, existing, values) {
+		err := tx.Create(&value).Error
+		if err == nil {
+			err = tx.Create(&LanguageTag{UserID: userID, RepoID: value.RepoID, Language: languages[value.RepoID], TagName: value.TagName}).Error
+		}
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		inserted++
+	}
+	return inserted, tx.Commit().Error
+}
+
+// newAutoRepoTags selects the automatic tags that the repos never had
+func newAutoRepoTags(userID string, existing []RepoTag, values []RepoTag) []RepoTag {
+	type repoTagKey struct {
+		repoID  int64
+		tagName string
+	}
+	seen := make(map[repoTagKey]bool)
+	for _, repoTag := range existing {
+		seen[repoTagKey{repoTag.RepoID, repoTag.TagName}] = true
+	}
+	var selected []RepoTag
+	for _, value := range values {
+		key := repoTagKey{value.RepoID, value.TagName}
+		if seen[key] || value.TagName == "" {
+			continue
+		}
+		seen[key] = true
+		value.UserID = userID
+		selected = append(selected, value)
+	}
+	return selected
+}
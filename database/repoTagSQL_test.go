@@ -0,0 +1,43 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+)
+
+func TestGormDeleteRepoTagsValue(t *testing.T) {
+	tt := map[string]struct {
+		rowsAffected int64
+		deleted      int
+	}{
+		"deleted": {1, 1},
+		"missing": {0, 0},
+	}
+	for testName, tc := range tt {
+		sqlDB, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn, err := gorm.Open("postgres", sqlDB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "repo_tags" SET "deleted_at"=.*user_id = \$2 AND repo_id = \$3 AND tag_name = \$4`).
+			WithArgs(sqlmock.AnyArg(), "joaopmgd", int64(10866521), "router").
+			WillReturnResult(sqlmock.NewResult(0, tc.rowsAffected))
+		mock.ExpectCommit()
+
+		deleted := (&Gorm{Conn: conn}).DeleteRepoTagsValue(RepoTag{UserID: "joaopmgd", RepoID: 10866521, TagName: "router"})
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("\nTest %s\nGot %v", testName, err)
+		}
+		if deleted != tc.deleted {
+			t.Errorf("\nTest %s\nGot %v deleted\nWant %v", testName, deleted, tc.deleted)
+		}
+		sqlDB.Close()
+	}
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// StarredRepo is the local copy of a repo starred by an user
+type StarredRepo struct {
+	gorm.Model
+
+	UserID      string `gorm:"index"`
+	RepoID      int64
+	FullName    string
+	Name        string
+	Description string
+	URL         string
+	Language    string
+	Topics      string
+	Stars       int
+	StarredAt   time.Time
+}
+
+// TopicsSeparator joins the topics of a repo in a single column
+const TopicsSeparator = ","
+
+// MirrorUser is an user whose starred repos are kept in sync
+type MirrorUser struct {
+	gorm.Model
+
+	UserID    string `gorm:"unique_index"`
+	SyncedAt  time.Time
+	SyncError string
+}
+
+// SaveStarredRepos replaces the user starred repos and registers the sync time
+func (db *Gorm) SaveStarredRepos(userID string, repos []StarredRepo, syncedAt time.Time) error {
+	tx := db.Conn.Begin()
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(StarredRepo{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, repo := range repos {
+		repo.UserID = userID
+		if err := tx.Create(&repo).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	var user MirrorUser
+	err := tx.Where(MirrorUser{UserID: userID}).
+		Assign(map[string]interface{}{"synced_at": syncedAt, "sync_error": ""}).
+		FirstOrCreate(&user).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// SaveMirrorError registers why the last sync of an already mirrored user failed
+func (db *Gorm) SaveMirrorError(userID string, reason string) error {
+	return db.Conn.Model(&MirrorUser{}).Where("user_id = ?", userID).Update("sync_error", reason).Error
+}
+
+// GetStarredRepos recovers the mirrored starred repos of an user, false if the user was never synced
+func (db *Gorm) GetStarredRepos(userID string) ([]StarredRepo, bool) {
+	var user MirrorUser
+	if db.Conn.Where("user_id = ?", userID).First(&user).RecordNotFound() {
+		return nil, false
+	}
+	var repos []StarredRepo
+	db.Conn.Where("user_id = ?", userID).Order("id").Find(&repos)
+	return repos, true
+}
+
+// GetMirrorUsers recovers every mirrored user
+func (db *Gorm) GetMirrorUsers() []MirrorUser {
+	var users []MirrorUser
+	db.Conn.Order("user_id").Find(&users)
+	return users
+}
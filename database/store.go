@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// TagStore is the storage used by the handlers to keep the repo and language tags
+type TagStore interface {
+	Ping() error
+
+	InsertRepoTagsValue(value RepoTag)
+	DeleteRepoTagsValue(value RepoTag) int
+	GetAllRepoTagsMap(userID string) map[int64][]string
+	GetAllRepoTagsByRepoID(userID string, repoID int64) []RepoTag
+	GetAllRepoTags(userID string) []RepoTag
+	InsertAutoRepoTags(userID string, values []RepoTag, languages map[int64]string) (int, error)
+	GetTagUsage(userID string) []TagUsage
+	MergeTags(userID string, from []string, to string) (int, error)
+	BulkUpdateRepoTags(userID string, changes []RepoTagChange) error
+	GetCoOccurringTags(tag string, limit int) []TagCount
+	GetRepoTagsByOtherUsers(userID string, repoID int64, limit int) []TagCount
+
+	InsertLanguageTagsValue(value LanguageTag)
+	GetRecommendationTagByLanguage(language string, limit, minCount int) []TagCount
+}
+
+// MirrorStore keeps a local copy of the users starred repos
+type MirrorStore interface {
+	SaveStarredRepos(userID string, repos []StarredRepo, syncedAt time.Time) error
+	SaveMirrorError(userID string, reason string) error
+	GetStarredRepos(userID string) ([]StarredRepo, bool)
+	GetMirrorUsers() []MirrorUser
+}
+
+// RuleStore keeps the auto tagging rules of the users
+type RuleStore interface {
+	InsertAutoTagRule(value *AutoTagRule) error
+	UpdateAutoTagRule(value AutoTagRule) (bool, error)
+	DeleteAutoTagRule(userID string, id uint) bool
+	GetAutoTagRule(userID string, id uint) (AutoTagRule, bool)
+	GetAutoTagRules(userID string) []AutoTagRule
+}
+
+// SettingsStore keeps the preferences of the users
+type SettingsStore interface {
+	GetUserSettings(userID string) (UserSettings, bool)
+	SaveUserSettings(value UserSettings) error
+}
+
+// KeyStore keeps the API keys issued to the users
+type KeyStore interface {
+	InsertAPIKey(value *APIKey) error
+	RevokeAPIKey(userID string, id uint) bool
+	GetAPIKeys(userID string) []APIKey
+	GetAPIKeyByHash(hash string) (APIKey, bool)
+	TouchAPIKey(id uint, usedAt time.Time) error
+}
+
+// Store has every storage used by the app
+type Store interface {
+	TagStore
+	MirrorStore
+	RuleStore
+	SettingsStore
+	KeyStore
+
+	// WithContext returns the store that traces its statements under the context of the request
+	WithContext(ctx context.Context) Store
+}
+
+// NewStore creates the Store for the selected driver, PostgreSQL is the default one
+func NewStore(driver string) (Store, error) {
+	switch driver {
+	case "", "postgres":
+		db, err := ConnectToDatabase()
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	case "memory":
+		return NewMemory(), nil
+	}
+	return nil, errors.New("Unknown database driver: " + driver)
+}
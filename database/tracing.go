@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jinzhu/gorm"
+	"github.com/joaopmgd/github-tag-api/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// contextKey keeps the request context in the Gorm settings, so its statements are traced under it
+const contextKey = "tracing:context"
+
+// spanKey keeps in the scope the span of the statement
+const spanKey = "tracing:span"
+
+// WithContext returns the store whose statements are traced under the context
+func (db *Gorm) WithContext(ctx context.Context) Store {
+	return &Gorm{Conn: db.Conn.Set(contextKey, ctx)}
+}
+
+// registerTracingCallbacks creates a span for the statement of every create, update, delete and query sent by the connection
+func registerTracingCallbacks(db *gorm.DB) {
+	callback := db.Callback()
+	// Each registration needs its own processor, so the map has the functions that create them
+	for operation, processor := range map[string]func() *gorm.CallbackProcessor{
+		"create":    callback.Create,
+		"update":    callback.Update,
+		"delete":    callback.Delete,
+		"query":     callback.Query,
+		"row_query": callback.RowQuery,
+	} {
+		operation := operation
+		processor().Before("gorm:"+operation).Register("tracing:before_"+operation, func(scope *gorm.Scope) {
+			ctx := context.Background()
+			if value, ok := scope.Get(contextKey); ok {
+				ctx = value.(context.Context)
+			}
+			table := scope.TableName()
+			_, span := tracing.Tracer().Start(ctx, "gorm "+operation+" "+table, trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String("db.system", "postgresql"),
+					attribute.String("db.operation.name", operation),
+					attribute.String("db.collection.name", table),
+				))
+			scope.InstanceSet(spanKey, span)
+		})
+		processor().After("gorm:"+operation).Register("tracing:after_"+operation, func(scope *gorm.Scope) {
+			value, ok := scope.InstanceGet(spanKey)
+			if !ok {
+				return
+			}
+			span := value.(trace.Span)
+			span.SetAttributes(attribute.String("db.query.text", scope.SQL))
+			if err := scope.DB().Error; err != nil && !gorm.IsRecordNotFoundError(err) {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		})
+	}
+}
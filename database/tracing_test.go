@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/joaopmgd/github-tag-api/tracing"
+	"github.com/joaopmgd/github-tag-api/tracing/tracingtest"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestTracingCallbacks(t *testing.T) {
+	exporter := tracingtest.Install(t)
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	conn, err := gorm.Open("postgres", sqlDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerTracingCallbacks(conn)
+	mock.ExpectQuery(`SELECT \* FROM "user_settings"`).WillReturnRows(sqlmock.NewRows([]string{"user_id", "private"}))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "repo_tags"`).WillReturnError(errors.New("connection lost"))
+	mock.ExpectRollback()
+	ctx, parent := tracing.Tracer().Start(context.Background(), "request")
+
+	store := (&Gorm{Conn: conn}).WithContext(ctx)
+	store.GetUserSettings("joaopmgd")
+	store.InsertRepoTagsValue(RepoTag{UserID: "joaopmgd", RepoID: 10866521, TagName: "router"})
+	parent.End()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("Got spans %v\nWant the query, the create and the request", spans)
+	}
+	tt := []struct {
+		name   string
+		status codes.Code
+	}{
+		{"gorm query user_settings", codes.Unset},
+		{"gorm create repo_tags", codes.Error},
+	}
+	for i, tc := range tt {
+		span := spans[i]
+		if span.Name != tc.name || span.Parent.SpanID() != parent.SpanContext().SpanID() || span.Status.Code != tc.status {
+			t.Errorf("\nTest %s\nGot span %s under %s with status %v\nWant it under %s with status %v",
+				tc.name, span.Name, span.Parent.SpanID(), span.Status.Code, parent.SpanContext().SpanID(), tc.status)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package database
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// UserSettings are the preferences of an user
+type UserSettings struct {
+	gorm.Model
+
+	UserID  string `gorm:"unique_index"`
+	Private bool
+}
+
+// GetUserSettings recovers the settings of an user, false if they were never saved
+func (db *Gorm) GetUserSettings(userID string) (UserSettings, bool) {
+	var settings UserSettings
+	if db.Conn.Where("user_id = ?", userID).First(&settings).RecordNotFound() {
+		return UserSettings{}, false
+	}
+	return settings, true
+}
+
+// SaveUserSettings creates or replaces the settings of an user
+func (db *Gorm) SaveUserSettings(value UserSettings) error {
+	var settings UserSettings
+	return db.Conn.Where(UserSettings{UserID: value.UserID}).
+		Assign(map[string]interface{}{"private": value.Private}).
+		FirstOrCreate(&settings).Error
+}
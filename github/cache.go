@@ -0,0 +1,96 @@
+package github
+
+import (
+	"strings"
+	"time"
+
+	"github.com/joaopmgd/github-tag-api/app/model"
+)
+
+// cacheRetention is how long a starred list is kept after it was stored, its ETags are reused to revalidate it until then
+const cacheRetention = time.Hour
+
+// cachePruneInterval is how often the lists kept past cacheRetention are removed
+const cachePruneInterval = time.Minute
+
+// DefaultMaxCachedUsers caps how many starred lists are cached, the oldest one is removed to store a new one
+const DefaultMaxCachedUsers = 1000
+
+// cachedPage is a page of starred repos with the ETag used to revalidate it
+type cachedPage struct {
+	URL   string
+	ETag  string
+	Next  string
+	Repos []model.StarredRepoRequest
+}
+
+// cachedStarredRepos is the last starred list requested for an user
+type cachedStarredRepos struct {
+	Pages    []cachedPage
+	StoredAt time.Time
+}
+
+// repos merges the pages in a new slice, so the cache can not be changed by the caller
+func (c cachedStarredRepos) repos() []model.StarredRepoRequest {
+	starredRepos := []model.StarredRepoRequest{}
+	for _, page := range c.Pages {
+		starredRepos = append(starredRepos, page.Repos...)
+	}
+	return starredRepos
+}
+
+// cacheKey normalizes the user, Github logins are case insensitive
+func cacheKey(user string) string {
+	return strings.ToLower(user)
+}
+
+func (c *Client) cachedStarredRepos(user string) (cachedStarredRepos, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, found := c.cache[cacheKey(user)]
+	return cached, found
+}
+
+func (c *Client) storeStarredRepos(user string, cached cachedStarredRepos) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pruneCache(cached.StoredAt)
+	key := cacheKey(user)
+	if _, found := c.cache[key]; !found && c.MaxCachedUsers > 0 && len(c.cache) >= c.MaxCachedUsers {
+		c.evictOldest()
+	}
+	c.cache[key] = cached
+}
+
+// pruneCache removes the lists stored before cacheRetention, the lock must be held by the caller
+func (c *Client) pruneCache(now time.Time) {
+	if now.Sub(c.lastPrune) < cachePruneInterval {
+		return
+	}
+	c.lastPrune = now
+	for key, cached := range c.cache {
+		if now.Sub(cached.StoredAt) >= cacheRetention {
+			delete(c.cache, key)
+		}
+	}
+}
+
+// evictOldest removes the list stored first, the lock must be held by the caller
+func (c *Client) evictOldest() {
+	oldest := ""
+	for key, cached := range c.cache {
+		if oldest == "" || cached.StoredAt.Before(c.cache[oldest].StoredAt) {
+			oldest = key
+		}
+	}
+	delete(c.cache, oldest)
+}
+
+// InvalidateStarredRepos removes the user starred list from the cache, the next request will fetch it again
+func (c *Client) InvalidateStarredRepos(user string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, found := c.cache[cacheKey(user)]
+	delete(c.cache, cacheKey(user))
+	return found
+}
@@ -0,0 +1,96 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/joaopmgd/github-tag-api/app/model"
+)
+
+func TestStarredReposCache(t *testing.T) {
+	var requests, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		json.NewEncoder(w).Encode([]model.StarredRepoRequest{{ID: 1, Name: "mux"}})
+	}))
+	defer server.Close()
+	client := NewClient(nil, 0, 0)
+	client.CacheTTL = time.Hour
+
+	steps := []struct {
+		name                string
+		before              func()
+		expectedRequests    int
+		expectedNotModified int
+		expectedChanged     bool
+	}{
+		{"first_request", func() {}, 1, 0, true},
+		{"fresh_cache", func() {}, 1, 0, false},
+		{"expired_cache", func() { client.CacheTTL = 0 }, 2, 1, false},
+		{"invalidated_cache", func() { client.InvalidateStarredRepos("JOAOPMGD") }, 3, 1, true},
+	}
+	for _, step := range steps {
+		step.before()
+
+		repos, changed, err := client.GetStarredReposChanged(context.Background(), "joaopmgd", server.URL)
+
+		if err != nil || len(repos) != 1 || repos[0].Name != "mux" {
+			t.Errorf("\nStep %s\nGot repos %v and error %v", step.name, repos, err)
+		}
+		if requests != step.expectedRequests || notModified != step.expectedNotModified {
+			t.Errorf("\nStep %s\nGot %d requests and %d not modified\nWant %d requests and %d not modified",
+				step.name, requests, notModified, step.expectedRequests, step.expectedNotModified)
+		}
+		if changed != step.expectedChanged {
+			t.Errorf("\nStep %s\nGot changed %v\nWant %v", step.name, changed, step.expectedChanged)
+		}
+	}
+}
+
+func TestStarredReposCacheBounded(t *testing.T) {
+	client := NewClient(nil, 0, 0)
+	client.MaxCachedUsers = 2
+	start := time.Unix(1000, 0)
+	steps := []struct {
+		name   string
+		user   string
+		at     time.Duration
+		cached []string
+	}{
+		{"first", "a", 0, []string{"a"}},
+		{"second", "b", time.Second, []string{"a", "b"}},
+		{"oldest_evicted", "c", 2 * time.Second, []string{"b", "c"}},
+		{"replaced_not_evicted", "c", 3 * time.Second, []string{"b", "c"}},
+		{"expired_pruned", "d", cacheRetention + 2*time.Second, []string{"c", "d"}},
+	}
+	for _, step := range steps {
+
+		client.storeStarredRepos(step.user, cachedStarredRepos{StoredAt: start.Add(step.at)})
+
+		for _, user := range []string{"a", "b", "c", "d"} {
+			_, found := client.cachedStarredRepos(user)
+			if want := contains(step.cached, user); found != want {
+				t.Errorf("\nTest %s\nGot %s cached %v, want %v", step.name, user, found, want)
+			}
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
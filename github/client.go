@@ -0,0 +1,348 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/joaopmgd/github-tag-api/metrics"
+	"github.com/joaopmgd/github-tag-api/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultPerPage is the page size requested to Github, 100 is the biggest one allowed
+const DefaultPerPage = 100
+
+// DefaultMaxPages limits how many pages are followed for a single list
+const DefaultMaxPages = 10
+
+// starredMediaType makes Github send when each repo was starred, and the repo topics
+const starredMediaType = "application/vnd.github.v3.star+json, application/vnd.github.mercy-preview+json"
+
+// DefaultAPIHost is the host of the Github API, the only one the tokens of the pool are sent to
+const DefaultAPIHost = "api.github.com"
+
+// DefaultCacheTTL is how long a cached starred list is used without asking Github if it changed
+const DefaultCacheTTL = time.Minute
+
+// Client requests data from the Github API, authenticated by a pool of tokens when they are set
+type Client struct {
+	HTTP     *http.Client
+	PerPage  int
+	MaxPages int
+	CacheTTL time.Duration
+	// APIHost receives the tokens of the pool, the requests to any other host are anonymous
+	APIHost string
+	// MaxCachedUsers caps the starred lists kept in the cache, zero keeps every one of them
+	MaxCachedUsers int
+
+	mu     sync.Mutex
+	tokens []string
+	next   int
+	limits map[string]model.GithubRateLimit
+	cache  map[string]cachedStarredRepos
+	// lastPrune is when the expired lists were last removed from the cache
+	lastPrune time.Time
+}
+
+// NewClient creates a Github client, the tokens are used in turns and perPage and maxPages control how the lists are paginated
+func NewClient(tokens []string, perPage, maxPages int) *Client {
+	if perPage <= 0 || perPage > DefaultPerPage {
+		perPage = DefaultPerPage
+	}
+	if maxPages <= 0 {
+		maxPages = DefaultMaxPages
+	}
+	return &Client{
+		HTTP:           &http.Client{Timeout: 10 * time.Second},
+		PerPage:        perPage,
+		MaxPages:       maxPages,
+		CacheTTL:       DefaultCacheTTL,
+		APIHost:        DefaultAPIHost,
+		MaxCachedUsers: DefaultMaxCachedUsers,
+		tokens:         tokens,
+		limits:         make(map[string]model.GithubRateLimit),
+		cache:          make(map[string]cachedStarredRepos),
+	}
+}
+
+// Operations sent to Github, labelling the metrics of each request
+const (
+	operationJSON        = "json"
+	operationStarred     = "starred"
+	operationUser        = "user"
+	operationDeviceCode  = "device_code"
+	operationDeviceToken = "device_token"
+)
+
+// do sends a GET request authenticated by the next token of the pool, the caller must close the body.
+// The request is anonymous when the URL is not on the API host, so the tokens never leave Github.
+func (c *Client) do(ctx context.Context, operation, URL string, header http.Header) (*http.Response, error) {
+	if !c.isAPIHost(URL) {
+		return c.doWithToken(ctx, operation, URL, header, "")
+	}
+	token := c.nextToken()
+	r, err := c.doWithToken(ctx, operation, URL, header, token)
+	if err != nil {
+		return nil, err
+	}
+	if c.recordRateLimit(token, r.Header) {
+		metrics.SetGithubRateLimit(c.RateLimit())
+	}
+	return r, nil
+}
+
+// doWithToken sends a GET request authenticated by the token, or anonymous when it is empty
+func (c *Client) doWithToken(ctx context.Context, operation, URL string, header http.Header, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "token "+token)
+	}
+	return c.send(operation, req)
+}
+
+// isAPIHost tells if the URL is on the Github API host
+func (c *Client) isAPIHost(URL string) bool {
+	u, err := url.Parse(URL)
+	return err == nil && c.APIHost != "" && strings.EqualFold(u.Host, c.APIHost)
+}
+
+// send sends the request in a span, recording its duration and status.
+// The span is propagated by the W3C trace context headers only to the API host, the other hosts do not get the trace.
+func (c *Client) send(operation string, req *http.Request) (*http.Response, error) {
+	ctx, span := tracing.Tracer().Start(req.Context(), "github "+operation, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("url.full", req.URL.String()),
+			attribute.String("github.operation", operation),
+		))
+	defer span.End()
+	req = req.WithContext(ctx)
+	if c.isAPIHost(req.URL.String()) {
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	}
+
+	start := time.Now()
+	r, err := c.HTTP.Do(req)
+	status := 0
+	if err == nil {
+		status = r.StatusCode
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= 500 {
+			span.SetStatus(codes.Error, r.Status)
+		}
+	} else {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	metrics.ObserveGithubRequest(operation, status, time.Since(start))
+	return r, err
+}
+
+// GetJSON requests the URL and decodes the JSON body into target, the response header is returned for pagination
+func (c *Client) GetJSON(ctx context.Context, URL string, target interface{}) (http.Header, error) {
+	r, err := c.do(ctx, operationJSON, URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON(r, target)
+}
+
+// GetPublicJSON requests the URL anonymously and decodes the JSON body into target, for the services outside of the API as the status page
+func (c *Client) GetPublicJSON(ctx context.Context, URL string, target interface{}) (http.Header, error) {
+	r, err := c.doWithToken(ctx, operationJSON, URL, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON(r, target)
+}
+
+// decodeJSON decodes the JSON body of a successful response into target, closing it
+func decodeJSON(r *http.Response, target interface{}) (http.Header, error) {
+	defer r.Body.Close()
+	if r.StatusCode < 200 || r.StatusCode > 299 {
+		return r.Header, responseError(r)
+	}
+	return r.Header, json.NewDecoder(r.Body).Decode(target)
+}
+
+// GetStarredRepos requests every page of the user starred repos, following the Link header until the last page or MaxPages.
+// The list is cached by user, it is reused for CacheTTL and after that every page is revalidated with its ETag.
+func (c *Client) GetStarredRepos(ctx context.Context, user, URL string) ([]model.StarredRepoRequest, error) {
+	repos, _, err := c.GetStarredReposChanged(ctx, user, URL)
+	return repos, err
+}
+
+// GetStarredReposChanged requests the starred repos as GetStarredRepos, also telling if they changed since they were cached.
+// A list that was not cached, or whose pages have no ETag, is always changed.
+func (c *Client) GetStarredReposChanged(ctx context.Context, user, URL string) ([]model.StarredRepoRequest, bool, error) {
+	cached, found := c.cachedStarredRepos(user)
+	if found && time.Since(cached.StoredAt) < c.CacheTTL {
+		return cached.repos(), false, nil
+	}
+	pageURL, err := c.withPerPage(URL)
+	if err != nil {
+		return nil, false, err
+	}
+	fetched := cachedStarredRepos{StoredAt: time.Now()}
+	changed := !found
+	for page := 0; page < c.MaxPages && pageURL != ""; page++ {
+		var previous *cachedPage
+		if page < len(cached.Pages) && cached.Pages[page].URL == pageURL {
+			previous = &cached.Pages[page]
+		}
+		current, err := c.getStarredPage(ctx, pageURL, previous)
+		if err != nil {
+			return nil, false, err
+		}
+		if previous == nil || current.ETag == "" || current.ETag != previous.ETag {
+			changed = true
+		}
+		fetched.Pages = append(fetched.Pages, current)
+		pageURL = current.Next
+	}
+	if len(fetched.Pages) != len(cached.Pages) {
+		changed = true
+	}
+	c.storeStarredRepos(user, fetched)
+	return fetched.repos(), changed, nil
+}
+
+// getStarredPage requests a single page, sending If-None-Match so an unchanged page is reused from the cache
+func (c *Client) getStarredPage(ctx context.Context, URL string, previous *cachedPage) (cachedPage, error) {
+	header := http.Header{}
+	header.Set("Accept", starredMediaType)
+	if previous != nil && previous.ETag != "" {
+		header.Set("If-None-Match", previous.ETag)
+	}
+	r, err := c.do(ctx, operationStarred, URL, header)
+	if err != nil {
+		return cachedPage{}, err
+	}
+	defer r.Body.Close()
+	if r.StatusCode == http.StatusNotModified && previous != nil {
+		return *previous, nil
+	}
+	if r.StatusCode < 200 || r.StatusCode > 299 {
+		return cachedPage{}, responseError(r)
+	}
+	var items []starredItem
+	if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+		return cachedPage{}, err
+	}
+	page := cachedPage{URL: URL, ETag: r.Header.Get("ETag"), Next: NextPageURL(r.Header.Get("Link"))}
+	for _, item := range items {
+		item.Repo.StarredAt = item.StarredAt
+		page.Repos = append(page.Repos, item.Repo)
+	}
+	return page, nil
+}
+
+// nextToken picks the next token of the pool, skipping the ones without quota until their reset
+func (c *Client) nextToken() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.tokens) == 0 {
+		return ""
+	}
+	now := time.Now()
+	earliest := c.tokens[c.next]
+	for i := 0; i < len(c.tokens); i++ {
+		token := c.tokens[c.next]
+		c.next = (c.next + 1) % len(c.tokens)
+		limit, ok := c.limits[token]
+		if !ok || limit.Remaining > 0 || now.After(limit.Reset) {
+			return token
+		}
+		if limit.Reset.Before(c.limits[earliest].Reset) {
+			earliest = token
+		}
+	}
+	// Every token is exhausted, the one that resets first is the best bet
+	return earliest
+}
+
+// recordRateLimit keeps the quota sent in the X-RateLimit headers for the token used, false if there was no quota
+func (c *Client) recordRateLimit(token string, header http.Header) bool {
+	limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return false
+	}
+	remaining, _ := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	reset, _ := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limits[token] = model.GithubRateLimit{Limit: limit, Remaining: remaining, Reset: time.Unix(reset, 0).UTC()}
+	return true
+}
+
+// RateLimit sums the last quota reported by Github for every token, the reset is the earliest one
+func (c *Client) RateLimit() model.GithubRateLimit {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rateLimit := model.GithubRateLimit{Tokens: len(c.tokens)}
+	for _, limit := range c.limits {
+		rateLimit.Limit += limit.Limit
+		rateLimit.Remaining += limit.Remaining
+		if rateLimit.Reset.IsZero() || limit.Reset.Before(rateLimit.Reset) {
+			rateLimit.Reset = limit.Reset
+		}
+	}
+	return rateLimit
+}
+
+// withPerPage adds the per_page query parameter to the URL, unless it is already set
+func (c *Client) withPerPage(URL string) (string, error) {
+	u, err := url.Parse(URL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	if q.Get("per_page") == "" {
+		q.Set("per_page", strconv.Itoa(c.PerPage))
+		u.RawQuery = q.Encode()
+	}
+	return u.String(), nil
+}
+
+// NextPageURL returns the rel="next" URL from a Link header, or an empty string on the last page
+func NextPageURL(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		sections := strings.Split(part, ";")
+		if len(sections) < 2 {
+			continue
+		}
+		for _, param := range sections[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(sections[0]), "<>")
+			}
+		}
+	}
+	return ""
+}
+
+// responseError creates an error with the message sent by Github
+func responseError(r *http.Response) error {
+	var requestError model.RequestError
+	if err := json.NewDecoder(r.Body).Decode(&requestError); err != nil || requestError.Message == "" {
+		return errors.New("Github responded with status " + r.Status)
+	}
+	return errors.New("Github responded with status " + r.Status + ": " + requestError.Message)
+}
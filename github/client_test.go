@@ -0,0 +1,198 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/joaopmgd/github-tag-api/tracing"
+	"github.com/joaopmgd/github-tag-api/tracing/tracingtest"
+)
+
+// newPaginatedServer serves pages starred repos, each one with a single repo whose id is the page number
+func newPaginatedServer(t *testing.T, pages int) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/users/joaopmgd/starred" {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(model.RequestError{Message: "Not Found"})
+			return
+		}
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		if err != nil {
+			page = 1
+		}
+		if page < pages {
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?page=%d&per_page=%s>; rel="next", <%s%s?page=%d>; rel="last"`,
+				server.URL, r.URL.Path, page+1, r.URL.Query().Get("per_page"), server.URL, r.URL.Path, pages))
+		}
+		json.NewEncoder(w).Encode([]model.StarredRepoRequest{{ID: int64(page)}})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGetStarredRepos(t *testing.T) {
+	tt := map[string]struct {
+		pages       int
+		maxPages    int
+		path        string
+		expectedIDs []int64
+		expectError bool
+	}{
+		"single_page":      {1, 10, "/users/joaopmgd/starred", []int64{1}, false},
+		"follow_next_link": {3, 10, "/users/joaopmgd/starred", []int64{1, 2, 3}, false},
+		"page_cap":         {5, 2, "/users/joaopmgd/starred", []int64{1, 2}, false},
+		"user_not_found":   {1, 10, "/users/nobody/starred", nil, true},
+	}
+	for testName, tc := range tt {
+		server := newPaginatedServer(t, tc.pages)
+		client := NewClient(nil, 1, tc.maxPages)
+
+		repos, err := client.GetStarredRepos(context.Background(), testName, server.URL+tc.path)
+
+		if (err != nil) != tc.expectError {
+			t.Errorf("\nTest %s\nGot error %v, want error %v", testName, err, tc.expectError)
+			continue
+		}
+		var ids []int64
+		for _, repo := range repos {
+			ids = append(ids, repo.ID)
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(tc.expectedIDs) {
+			t.Errorf("\nTest %s\nGot ids %v\nWant ids %v", testName, ids, tc.expectedIDs)
+		}
+	}
+}
+
+func TestNextPageURL(t *testing.T) {
+	tt := map[string]struct {
+		link string
+		next string
+	}{
+		"empty_header": {"", ""},
+		"next_and_last": {`<https://api.github.com/user/1/starred?page=2>; rel="next", <https://api.github.com/user/1/starred?page=5>; rel="last"`,
+			"https://api.github.com/user/1/starred?page=2"},
+		"last_page": {`<https://api.github.com/user/1/starred?page=1>; rel="first", <https://api.github.com/user/1/starred?page=4>; rel="prev"`, ""},
+	}
+	for testName, tc := range tt {
+
+		next := NextPageURL(tc.link)
+
+		if next != tc.next {
+			t.Errorf("\nTest %s\nGot %s\nWant %s", testName, next, tc.next)
+		}
+	}
+}
+
+func TestTokenPool(t *testing.T) {
+	var used []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		used = append(used, r.Header.Get("Authorization"))
+		remaining := "4999"
+		if r.Header.Get("Authorization") == "token exhausted" {
+			remaining = "0"
+		}
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", remaining)
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		json.NewEncoder(w).Encode([]model.StarredRepoRequest{})
+	}))
+	defer server.Close()
+	client := NewClient([]string{"exhausted", "available"}, 0, 0)
+	client.APIHost = strings.TrimPrefix(server.URL, "http://")
+
+	for i := 0; i < 4; i++ {
+		var repos []model.StarredRepoRequest
+		if _, err := client.GetJSON(context.Background(), server.URL, &repos); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{"token exhausted", "token available", "token available", "token available"}
+	if fmt.Sprint(used) != fmt.Sprint(want) {
+		t.Errorf("\nGot tokens %v\nWant tokens %v", used, want)
+	}
+	rateLimit := client.RateLimit()
+	if rateLimit.Limit != 10000 || rateLimit.Remaining != 4999 || rateLimit.Tokens != 2 {
+		t.Errorf("\nGot rate limit %+v\nWant limit 10000, remaining 4999 and 2 tokens", rateLimit)
+	}
+}
+
+func TestTokensOnlyForAPIHost(t *testing.T) {
+	used := map[string]string{}
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		used["other"] = r.Header.Get("Authorization")
+		json.NewEncoder(w).Encode([]model.StarredRepoRequest{{ID: 2}})
+	}))
+	defer other.Close()
+	API := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		used["api"] = r.Header.Get("Authorization")
+		w.Header().Set("Link", `<`+other.URL+`/users/joaopmgd/starred?page=2>; rel="next"`)
+		json.NewEncoder(w).Encode([]model.StarredRepoRequest{{ID: 1}})
+	}))
+	defer API.Close()
+	client := NewClient([]string{"secret"}, 0, 0)
+	client.APIHost = strings.TrimPrefix(API.URL, "http://")
+
+	if _, err := client.GetStarredRepos(context.Background(), "joaopmgd", API.URL+"/users/joaopmgd/starred"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetPublicJSON(context.Background(), API.URL, &[]model.StarredRepoRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if used["other"] != "" {
+		t.Errorf("Got token %q sent to the next page on another host, want none", used["other"])
+	}
+	if used["api"] != "" {
+		t.Errorf("Got token %q sent by the public request, want none", used["api"])
+	}
+	if _, err := client.GetJSON(context.Background(), API.URL, &[]model.StarredRepoRequest{}); err != nil || used["api"] != "token secret" {
+		t.Errorf("Got token %q and error %v sent to the API host, want token secret", used["api"], err)
+	}
+}
+
+func TestGetJSONTraced(t *testing.T) {
+	exporter := tracingtest.Install(t)
+	traceparents := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents[r.URL.Path] = r.Header.Get("traceparent")
+		json.NewEncoder(w).Encode(model.GithubUser{Login: "joaopmgd"})
+	}))
+	defer server.Close()
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents["other"] = r.Header.Get("traceparent")
+		json.NewEncoder(w).Encode(model.GithubUser{Login: "joaopmgd"})
+	}))
+	defer other.Close()
+	client := NewClient(nil, 1, 1)
+	client.APIHost = strings.TrimPrefix(server.URL, "http://")
+	ctx, parent := tracing.Tracer().Start(context.Background(), "request")
+
+	_, err := client.GetJSON(ctx, server.URL+"/user", &model.GithubUser{})
+	_, otherErr := client.GetPublicJSON(ctx, other.URL, &model.GithubUser{})
+	parent.End()
+
+	if err != nil || otherErr != nil {
+		t.Fatalf("Got errors %v and %v, want none", err, otherErr)
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != 3 || spans[0].Name != "github json" || spans[0].Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("Got spans %v\nWant the github json spans under the request span", spans)
+	}
+	want := "00-" + spans[0].SpanContext.TraceID().String() + "-" + spans[0].SpanContext.SpanID().String() + "-01"
+	if traceparents["/user"] != want {
+		t.Errorf("Got traceparent %s, want %s", traceparents["/user"], want)
+	}
+	if traceparents["other"] != "" {
+		t.Errorf("Got traceparent %s sent to another host, want none", traceparents["other"])
+	}
+}
@@ -0,0 +1,32 @@
+package github
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/joaopmgd/github-tag-api/app/model"
+)
+
+// starredItem is a starred repo in the star media type, {"starred_at": ..., "repo": {...}}
+type starredItem struct {
+	StarredAt time.Time                `json:"starred_at"`
+	Repo      model.StarredRepoRequest `json:"repo"`
+}
+
+// UnmarshalJSON also accepts a plain repo, for servers that ignore the star media type
+func (s *starredItem) UnmarshalJSON(data []byte) error {
+	var item struct {
+		StarredAt time.Time                 `json:"starred_at"`
+		Repo      *model.StarredRepoRequest `json:"repo"`
+	}
+	if err := json.Unmarshal(data, &item); err != nil {
+		return err
+	}
+	if item.Repo == nil {
+		s.StarredAt = time.Time{}
+		return json.Unmarshal(data, &s.Repo)
+	}
+	s.StarredAt = item.StarredAt
+	s.Repo = *item.Repo
+	return nil
+}
@@ -0,0 +1,78 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/joaopmgd/github-tag-api/app/model"
+)
+
+// ErrUnauthorized is returned when Github refuses the token of an user
+var ErrUnauthorized = errors.New("Github refused the token")
+
+// deviceGrantType is the grant used to exchange a device code for a token
+const deviceGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// GetAuthenticatedUser requests the user that owns the token, the pool tokens are not used
+func (c *Client) GetAuthenticatedUser(ctx context.Context, URL, token string) (model.GithubUser, error) {
+	r, err := c.doWithToken(ctx, operationUser, URL, nil, token)
+	if err != nil {
+		return model.GithubUser{}, err
+	}
+	defer r.Body.Close()
+	if r.StatusCode == http.StatusUnauthorized || r.StatusCode == http.StatusForbidden {
+		return model.GithubUser{}, ErrUnauthorized
+	}
+	if r.StatusCode < 200 || r.StatusCode > 299 {
+		return model.GithubUser{}, responseError(r)
+	}
+	var user model.GithubUser
+	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+		return model.GithubUser{}, err
+	}
+	if user.Login == "" {
+		return model.GithubUser{}, ErrUnauthorized
+	}
+	return user, nil
+}
+
+// RequestDeviceCode starts the OAuth device flow, the user must type the code in the verification URI
+func (c *Client) RequestDeviceCode(ctx context.Context, oauthURL, clientID, scope string) (model.GithubDeviceCode, error) {
+	var code model.GithubDeviceCode
+	err := c.postForm(ctx, operationDeviceCode, strings.TrimSuffix(oauthURL, "/")+"/login/device/code", url.Values{"client_id": {clientID}, "scope": {scope}}, &code)
+	return code, err
+}
+
+// PollDeviceToken asks if the user authorized the device code, the response has an error while it is pending
+func (c *Client) PollDeviceToken(ctx context.Context, oauthURL, clientID, deviceCode string) (model.GithubDeviceToken, error) {
+	var token model.GithubDeviceToken
+	err := c.postForm(ctx, operationDeviceToken, strings.TrimSuffix(oauthURL, "/")+"/login/oauth/access_token", url.Values{
+		"client_id":   {clientID},
+		"device_code": {deviceCode},
+		"grant_type":  {deviceGrantType},
+	}, &token)
+	return token, err
+}
+
+// postForm posts the values to the Github OAuth endpoints, which answer JSON when it is accepted
+func (c *Client) postForm(ctx context.Context, operation, URL string, values url.Values, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", URL, strings.NewReader(values.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	r, err := c.send(operation, req)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+	if r.StatusCode < 200 || r.StatusCode > 299 {
+		return responseError(r)
+	}
+	return json.NewDecoder(r.Body).Decode(target)
+}
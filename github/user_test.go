@@ -0,0 +1,80 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/joaopmgd/github-tag-api/app/model"
+)
+
+func TestGetAuthenticatedUser(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "token valid":
+			json.NewEncoder(w).Encode(model.GithubUser{Login: "joaopmgd", ID: 1})
+		case "token broken":
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(model.RequestError{Message: "Server Error"})
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(model.RequestError{Message: "Bad credentials"})
+		}
+	}))
+	defer server.Close()
+	tt := map[string]struct {
+		token        string
+		login        string
+		expectError  bool
+		unauthorized bool
+	}{
+		"valid_token":   {"valid", "joaopmgd", false, false},
+		"invalid_token": {"invalid", "", true, true},
+		"github_error":  {"broken", "", true, false},
+	}
+	for testName, tc := range tt {
+
+		user, err := NewClient([]string{"pool"}, 0, 0).GetAuthenticatedUser(context.Background(), server.URL+"/user", tc.token)
+
+		if user.Login != tc.login || (err != nil) != tc.expectError || (err == ErrUnauthorized) != tc.unauthorized {
+			t.Errorf("\nTest %s\nGot %+v and error %v\nWant login %s, error %v and unauthorized %v",
+				testName, user, err, tc.login, tc.expectError, tc.unauthorized)
+		}
+	}
+}
+
+func TestDeviceFlow(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Method != "POST" || r.Header.Get("Accept") != "application/json" || r.PostForm.Get("client_id") != "client" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/login/device/code":
+			json.NewEncoder(w).Encode(model.GithubDeviceCode{DeviceCode: "device", UserCode: "ABCD-1234", Interval: 5})
+		case "/login/oauth/access_token":
+			if r.PostForm.Get("grant_type") != deviceGrantType {
+				json.NewEncoder(w).Encode(model.GithubDeviceToken{Error: "unsupported_grant_type"})
+				return
+			}
+			json.NewEncoder(w).Encode(model.GithubDeviceToken{AccessToken: "gho_" + r.PostForm.Get("device_code")})
+		}
+	}))
+	defer server.Close()
+	client := NewClient(nil, 0, 0)
+
+	code, err := client.RequestDeviceCode(context.Background(), server.URL+"/", "client", "read:user")
+	if err != nil || code.DeviceCode != "device" || code.UserCode != "ABCD-1234" {
+		t.Fatalf("Got %+v and error %v\nWant the device code", code, err)
+	}
+	token, err := client.PollDeviceToken(context.Background(), server.URL, "client", code.DeviceCode)
+	if err != nil || token.AccessToken != "gho_device" {
+		t.Errorf("Got %+v and error %v\nWant the token gho_device", token, err)
+	}
+	if _, err := client.RequestDeviceCode(context.Background(), server.URL, "other", "read:user"); err == nil {
+		t.Errorf("Got no error for an unknown client\nWant an error")
+	}
+}
@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric of the app
+const namespace = "github_tag_api"
+
+// Registry has every metric served by Handler, with the Go runtime and process ones
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of the HTTP requests by route template, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	githubRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "github_requests_total",
+		Help:      "Requests sent to Github by operation and status, error when there was no response.",
+	}, []string{"operation", "status"})
+
+	githubRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "github_request_duration_seconds",
+		Help:      "Duration of the requests sent to Github by operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	githubRateLimit = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "github_rate_limit",
+		Help:      "Github quota of every token, as reported by the X-RateLimit headers.",
+	}, []string{"kind"})
+
+	githubRateLimitReset = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "github_rate_limit_reset_timestamp_seconds",
+		Help:      "Unix time of the earliest Github quota reset.",
+	})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Duration of the database queries by operation and table.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"operation", "table"})
+
+	tagsAdded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tags_added_total",
+		Help:      "Tags added to repos by source, user, bulk, import or auto.",
+	}, []string{"source"})
+
+	tagsRemoved = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tags_removed_total",
+		Help:      "Tags removed from repos by source, user, bulk or import.",
+	}, []string{"source"})
+
+	recommendations = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "recommendations_total",
+		Help:      "Tag recommendations requested.",
+	})
+
+	recommendationSuggestions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "recommendation_suggestions_total",
+		Help:      "Reasons of the suggested tags by source, language, co_occurrence, other_users or content.",
+	}, []string{"source"})
+
+	rateLimitRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_requests_total",
+		Help:      "Requests checked by the rate limiter by route group and result, allowed or limited.",
+	}, []string{"group", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestDuration,
+		githubRequests,
+		githubRequestDuration,
+		githubRateLimit,
+		githubRateLimitReset,
+		dbQueryDuration,
+		tagsAdded,
+		tagsRemoved,
+		recommendations,
+		recommendationSuggestions,
+		rateLimitRequests,
+	)
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// ObserveHTTPRequest records a request served by the route template
+func ObserveHTTPRequest(route, method string, status int, duration time.Duration) {
+	httpRequestDuration.WithLabelValues(route, method, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// ObserveGithubRequest records a request sent to Github, the status is zero when it failed without a response
+func ObserveGithubRequest(operation string, status int, duration time.Duration) {
+	code := "error"
+	if status != 0 {
+		code = strconv.Itoa(status)
+	}
+	githubRequests.WithLabelValues(operation, code).Inc()
+	githubRequestDuration.WithLabelValues(operation).Observe(duration.Seconds())
+}
+
+// SetGithubRateLimit records the Github quota summed for every token
+func SetGithubRateLimit(rateLimit model.GithubRateLimit) {
+	githubRateLimit.WithLabelValues("limit").Set(float64(rateLimit.Limit))
+	githubRateLimit.WithLabelValues("remaining").Set(float64(rateLimit.Remaining))
+	githubRateLimitReset.Set(float64(rateLimit.Reset.Unix()))
+}
+
+// ObserveDBQuery records a database query on the table
+func ObserveDBQuery(operation, table string, duration time.Duration) {
+	dbQueryDuration.WithLabelValues(operation, table).Observe(duration.Seconds())
+}
+
+// AddTags counts the tags added to repos by the source
+func AddTags(source string, tags int) {
+	tagsAdded.WithLabelValues(source).Add(float64(tags))
+}
+
+// RemoveTags counts the tags removed from repos by the source
+func RemoveTags(source string, tags int) {
+	tagsRemoved.WithLabelValues(source).Add(float64(tags))
+}
+
+// ObserveRecommendation counts a recommendation and the reasons of its suggestions
+func ObserveRecommendation(suggestions []model.TagSuggestion) {
+	recommendations.Inc()
+	for _, suggestion := range suggestions {
+		for _, reason := range suggestion.Reasons {
+			recommendationSuggestions.WithLabelValues(reason.Source).Inc()
+		}
+	}
+}
+
+// ObserveRateLimit counts a request checked by the rate limiter of the route group
+func ObserveRateLimit(group string, allowed bool) {
+	result := "limited"
+	if allowed {
+		result = "allowed"
+	}
+	rateLimitRequests.WithLabelValues(group, result).Inc()
+}
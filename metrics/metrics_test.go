@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joaopmgd/github-tag-api/app/model"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveGithubRequest(t *testing.T) {
+	before := testutil.ToFloat64(githubRequests.WithLabelValues("starred", "error"))
+	beforeOK := testutil.ToFloat64(githubRequests.WithLabelValues("starred", "200"))
+
+	ObserveGithubRequest("starred", 0, time.Millisecond)
+	ObserveGithubRequest("starred", 200, time.Millisecond)
+	ObserveGithubRequest("starred", 200, time.Millisecond)
+
+	if failed := testutil.ToFloat64(githubRequests.WithLabelValues("starred", "error")) - before; failed != 1 {
+		t.Errorf("Got %v failed requests, want 1", failed)
+	}
+	if ok := testutil.ToFloat64(githubRequests.WithLabelValues("starred", "200")) - beforeOK; ok != 2 {
+		t.Errorf("Got %v requests with status 200, want 2", ok)
+	}
+}
+
+func TestSetGithubRateLimit(t *testing.T) {
+	reset := time.Unix(1564660800, 0)
+
+	SetGithubRateLimit(model.GithubRateLimit{Limit: 5000, Remaining: 4990, Reset: reset})
+
+	limit, remaining := testutil.ToFloat64(githubRateLimit.WithLabelValues("limit")), testutil.ToFloat64(githubRateLimit.WithLabelValues("remaining"))
+	if limit != 5000 || remaining != 4990 || testutil.ToFloat64(githubRateLimitReset) != 1564660800 {
+		t.Errorf("Got limit %v, remaining %v and reset %v\nWant 5000, 4990 and 1564660800", limit, remaining, testutil.ToFloat64(githubRateLimitReset))
+	}
+}
+
+func TestObserveRecommendation(t *testing.T) {
+	before := testutil.ToFloat64(recommendations)
+	beforeLanguage := testutil.ToFloat64(recommendationSuggestions.WithLabelValues("language"))
+
+	ObserveRecommendation([]model.TagSuggestion{
+		{Tag: "router", Reasons: []model.SuggestionReason{{Source: "language"}, {Source: "content"}}},
+		{Tag: "http", Reasons: []model.SuggestionReason{{Source: "language"}}},
+	})
+
+	if count := testutil.ToFloat64(recommendations) - before; count != 1 {
+		t.Errorf("Got %v recommendations, want 1", count)
+	}
+	if language := testutil.ToFloat64(recommendationSuggestions.WithLabelValues("language")) - beforeLanguage; language != 2 {
+		t.Errorf("Got %v language reasons, want 2", language)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	ObserveHTTPRequest("/repos/{user}/starred", "GET", 200, time.Millisecond)
+	AddTags("user", 1)
+	ObserveRateLimit("write", false)
+	rr := httptest.NewRecorder()
+
+	Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+
+	for _, want := range []string{
+		`github_tag_api_http_request_duration_seconds_count{method="GET",route="/repos/{user}/starred",status="200"}`,
+		`github_tag_api_tags_added_total{source="user"}`,
+		`github_tag_api_rate_limit_requests_total{group="write",result="limited"}`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("Got metrics without %s", want)
+		}
+	}
+}
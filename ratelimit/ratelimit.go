@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joaopmgd/github-tag-api/metrics"
+)
+
+// Route groups, each one has its own limit and buckets
+const (
+	GroupRead  = "read"
+	GroupWrite = "write"
+	GroupAuth  = "auth"
+)
+
+// pruneInterval is how often the idle buckets are removed
+const pruneInterval = time.Minute
+
+// periods are the units accepted by ParseLimit
+var periods = map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}
+
+// Limit allows Requests at once, refilled evenly along Period
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit reads a limit written as "{requests}/{period}", as "60/m", the period is s, m or h
+func ParseLimit(value string) (Limit, error) {
+	parts := strings.Split(strings.TrimSpace(value), "/")
+	if len(parts) != 2 {
+		return Limit{}, errors.New("Rate limit must be {requests}/{period}: " + value)
+	}
+	requests, err := strconv.Atoi(parts[0])
+	if err != nil || requests <= 0 {
+		return Limit{}, errors.New("Rate limit requests must be a positive number: " + value)
+	}
+	period, found := periods[parts[1]]
+	if !found {
+		return Limit{}, errors.New("Rate limit period must be s, m or h: " + value)
+	}
+	return Limit{Requests: requests, Period: period}, nil
+}
+
+// rate is how many tokens are refilled by nanosecond
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / float64(l.Period)
+}
+
+// Result is the state of a bucket after a request, Reset is when it will be full again
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Time
+	RetryAfter time.Duration
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Limiter keeps a token bucket for each client of each route group
+type Limiter struct {
+	mu        sync.Mutex
+	limits    map[string]Limit
+	buckets   map[string]*bucket
+	lastPrune time.Time
+}
+
+// New creates a limiter with the limit of each route group, the groups without a limit are not limited
+func New(limits map[string]Limit) *Limiter {
+	return &Limiter{limits: limits, buckets: make(map[string]*bucket)}
+}
+
+// Allow takes a token from the bucket of the client in the group, false if the group is not limited
+func (l *Limiter) Allow(group, client string, now time.Time) (Result, bool) {
+	limit, found := l.limits[group]
+	if !found {
+		return Result{}, false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prune(now)
+	key := group + "|" + client
+	b, found := l.buckets[key]
+	if !found {
+		b = &bucket{tokens: float64(limit.Requests), updated: now}
+		l.buckets[key] = b
+	}
+	rate := limit.rate()
+	b.tokens = math.Min(float64(limit.Requests), b.tokens+float64(now.Sub(b.updated))*rate)
+	b.updated = now
+
+	result := Result{Limit: limit.Requests}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1 - b.tokens) / rate))
+	}
+	metrics.ObserveRateLimit(group, result.Allowed)
+	result.Remaining = int(b.tokens)
+	result.Reset = now.Add(time.Duration(math.Ceil((float64(limit.Requests) - b.tokens) / rate)))
+	return result, true
+}
+
+// prune removes the buckets that are full again, the lock must be held by the caller
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < pruneInterval {
+		return
+	}
+	l.lastPrune = now
+	for key, b := range l.buckets {
+		group := key[:strings.Index(key, "|")]
+		if now.Sub(b.updated) >= l.limits[group].Period {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joaopmgd/github-tag-api/metrics"
+)
+
+func TestParseLimit(t *testing.T) {
+	tt := map[string]struct {
+		value       string
+		limit       Limit
+		expectError bool
+	}{
+		"per_second":   {"10/s", Limit{10, time.Second}, false},
+		"per_minute":   {" 60/m ", Limit{60, time.Minute}, false},
+		"per_hour":     {"1000/h", Limit{1000, time.Hour}, false},
+		"no_period":    {"10", Limit{}, true},
+		"zero":         {"0/s", Limit{}, true},
+		"wrong_period": {"10/d", Limit{}, true},
+		"not_a_number": {"ten/s", Limit{}, true},
+	}
+	for testName, tc := range tt {
+
+		limit, err := ParseLimit(tc.value)
+
+		if limit != tc.limit || (err != nil) != tc.expectError {
+			t.Errorf("\nTest %s\nGot %+v and error %v\nWant %+v and error %v", testName, limit, err, tc.limit, tc.expectError)
+		}
+	}
+}
+
+func TestLimiterAllow(t *testing.T) {
+	limiter := New(map[string]Limit{GroupWrite: {Requests: 2, Period: 10 * time.Second}})
+	start := time.Unix(1000, 0)
+	limitedBefore := limitedCount(t)
+	steps := []struct {
+		name      string
+		group     string
+		client    string
+		at        time.Duration
+		limited   bool
+		allowed   bool
+		remaining int
+		retry     time.Duration
+		reset     time.Duration
+	}{
+		{"first", GroupWrite, "a", 0, true, true, 1, 0, 5 * time.Second},
+		{"second", GroupWrite, "a", 0, true, true, 0, 0, 10 * time.Second},
+		{"exhausted", GroupWrite, "a", time.Second, true, false, 0, 4 * time.Second, 9 * time.Second},
+		{"other_client", GroupWrite, "b", time.Second, true, true, 1, 0, 5 * time.Second},
+		{"not_limited_group", GroupRead, "a", time.Second, false, false, 0, 0, -1000 * time.Second},
+		{"refilled", GroupWrite, "a", 5 * time.Second, true, true, 0, 0, 10 * time.Second},
+		{"full_again", GroupWrite, "a", time.Minute, true, true, 1, 0, 5 * time.Second},
+	}
+	for _, step := range steps {
+		now := start.Add(step.at)
+
+		result, limited := limiter.Allow(step.group, step.client, now)
+
+		if limited != step.limited || result.Allowed != step.allowed || result.Remaining != step.remaining ||
+			result.RetryAfter != step.retry || (limited && !result.Reset.Equal(now.Add(step.reset))) {
+			t.Errorf("\nTest %s\nGot %+v and limited %v\nWant allowed %v, remaining %v, retry after %v and reset in %v",
+				step.name, result, limited, step.allowed, step.remaining, step.retry, step.reset)
+		}
+	}
+	if len(limiter.buckets) != 1 {
+		t.Errorf("Got %v buckets, want the idle one pruned", len(limiter.buckets))
+	}
+	if limited := limitedCount(t) - limitedBefore; limited != 1 {
+		t.Errorf("Got %v limited requests, want 1", limited)
+	}
+}
+
+// limitedCount reads the rate_limit_requests_total counter of the limited write requests, it is shared by every test
+func limitedCount(t *testing.T) float64 {
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "github_tag_api_rate_limit_requests_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["group"] == GroupWrite && labels["result"] == "limited" {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
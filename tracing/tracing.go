@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Exporters of the spans, none keeps the tracing disabled
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// DefaultServiceName names the app in the spans when OTEL_SERVICE_NAME is not set
+const DefaultServiceName = "github-tag-api"
+
+// instrumentationName is the name of the tracer used by every span of the app
+const instrumentationName = "github.com/joaopmgd/github-tag-api"
+
+func init() {
+	// The W3C trace context is honoured even while the tracing is disabled, so it reaches Github and the logs
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+}
+
+// NewExporter creates the exporter by name, nil for none.
+// The OTLP exporter is configured by the OTEL_EXPORTER_OTLP_* environment variables.
+func NewExporter(ctx context.Context, name string) (sdktrace.SpanExporter, error) {
+	switch name {
+	case "", ExporterNone:
+		return nil, nil
+	case ExporterOTLP:
+		return otlptracehttp.New(ctx)
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	}
+	return nil, errors.New("Unknown tracing exporter: " + name)
+}
+
+// Install makes the spans of the app go to the exporter in batches, returning the function that flushes them on shutdown
+func Install(exporter sdktrace.SpanExporter, serviceName string) func(context.Context) error {
+	if exporter == nil {
+		return func(context.Context) error { return nil }
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown
+}
+
+// Tracer creates the spans of the app, from the global tracer provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
@@ -0,0 +1,52 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestNewExporter(t *testing.T) {
+	tt := map[string]struct {
+		name        string
+		expectNil   bool
+		expectError bool
+	}{
+		"default": {"", true, false},
+		"none":    {ExporterNone, true, false},
+		"stdout":  {ExporterStdout, false, false},
+		"memory":  {"memory", true, true},
+		"unknown": {"jaeger", true, true},
+	}
+	for testName, tc := range tt {
+
+		exporter, err := NewExporter(context.Background(), tc.name)
+
+		if (err != nil) != tc.expectError || (exporter == nil) != tc.expectNil {
+			t.Errorf("\nTest %s\nGot exporter %v and error %v\nWant nil exporter %v and error %v", testName, exporter, err, tc.expectNil, tc.expectError)
+		}
+	}
+}
+
+func TestInstall(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(previous)
+	exporter := tracetest.NewInMemoryExporter()
+	shutdown := Install(exporter, "test")
+	defer shutdown(context.Background())
+
+	_, span := Tracer().Start(context.Background(), "test span")
+	span.End()
+	otel.GetTracerProvider().(*sdktrace.TracerProvider).ForceFlush(context.Background())
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "test span" {
+		t.Fatalf("Got spans %v\nWant the test span", spans)
+	}
+	if service, _ := spans[0].Resource.Set().Value("service.name"); service.AsString() != "test" {
+		t.Errorf("Got service name %s, want test", service.AsString())
+	}
+}